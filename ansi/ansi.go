@@ -0,0 +1,427 @@
+package ansi
+
+import (
+	"bytes"
+	"godown/ast"
+	"godown/eastasian"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 水平線の既定の幅
+const defaultRuleWidth = 72
+
+// SGR(Select Graphic Rendition)のパラメータ
+const (
+	bold          = "1"
+	faint         = "2"
+	italic        = "3"
+	strikethrough = "9"
+	cyan          = "36"
+)
+
+// 見出しのレベルごとの色(h4以降は最後の色)
+var headingColors = []string{"35", "34", "32", "33"}
+
+// ASTを端末に表示するためのテキストとして出力するレンダラー
+//   - 強調、打ち消し、見出しはエスケープシーケンスで装飾する
+//   - コードブロックは罫線で囲む
+//   - リストの記号は•
+//   - 脚注の参照は[1]、脚注は文書の最後に番号を付けて出力する
+//
+// NoColorの場合はエスケープシーケンスを出力せず、見出しには下線を引く
+type Renderer struct {
+	Wrap    int  // 折り返す幅(0の場合は折り返さない)
+	NoColor bool // エスケープシーケンスを出力しない
+}
+
+// srcを端末に表示するテキストに変換する
+func Text(src string, r *Renderer) string {
+	l := lexer.New(src)
+	p := parser.New(l)
+	document := p.ParseDocument()
+
+	var out bytes.Buffer
+	r.Render(&out, document)
+
+	return out.String()
+}
+
+func (r *Renderer) Render(w io.Writer, doc *ast.Document) error {
+	var out bytes.Buffer
+
+	for i, block := range doc.Blocks {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		r.writeBlock(&out, block)
+	}
+	r.writeFootnotes(&out, doc.Footnotes)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// 参照された脚注を水平線で本文と区切って出力する
+// 2行目以降は番号の幅だけ字下げする
+func (r *Renderer) writeFootnotes(out *bytes.Buffer, footnotes []*ast.Footnote) {
+	for _, footnote := range footnotes {
+		if footnote.Index == 0 {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		if footnote.Index == 1 {
+			r.writeBlock(out, &ast.HorizontalRule{})
+			out.WriteString("\n")
+		}
+
+		mark := "[" + strconv.Itoa(footnote.Index) + "] "
+
+		sub := *r
+		if sub.Wrap > len(mark) {
+			sub.Wrap -= len(mark)
+		}
+		var body bytes.Buffer
+		for i, block := range footnote.Blocks {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			sub.writeBlock(&body, block)
+		}
+
+		for i, line := range strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n") {
+			switch {
+			case i == 0:
+				line = r.style(mark, []string{faint}) + line
+			case line != "":
+				line = strings.Repeat(" ", len(mark)) + line
+			}
+			out.WriteString(line + "\n")
+		}
+	}
+}
+
+func (r *Renderer) writeBlock(out *bytes.Buffer, block ast.Block) {
+	switch block := block.(type) {
+	case *ast.Heading:
+		color := headingColors[len(headingColors)-1]
+		if block.Level <= len(headingColors) {
+			color = headingColors[block.Level-1]
+		}
+
+		lines := wrap(spans(block.Contents, []string{bold, color}), r.Wrap)
+		r.writeLines(out, lines, "", "")
+
+		if r.NoColor {
+			underline := "-"
+			if block.Level == 1 {
+				underline = "="
+			}
+			out.WriteString(strings.Repeat(underline, maxWidth(lines)))
+			out.WriteString("\n")
+		}
+	case *ast.DiscList:
+		for i, item := range block.Lists {
+			r.writeLines(out, wrap(spans(item, nil), r.Wrap-2), "• ", "  ")
+			r.writeItemBlocks(out, block.ItemBlocks(i))
+		}
+	case *ast.Paragraph:
+		r.writeLines(out, wrap(spans(block.Contents, nil), r.Wrap), "", "")
+	case *ast.CodeBlock:
+		r.writeCodeBlock(out, block)
+	case *ast.HorizontalRule:
+		width := r.Wrap
+		if width <= 0 {
+			width = defaultRuleWidth
+		}
+		out.WriteString(r.style(strings.Repeat("─", width), []string{faint}))
+		out.WriteString("\n")
+	}
+}
+
+// リストの項目の続きのブロックを、項目の内容の位置まで字下げして出力する
+// 入れ子のリスト以外のブロックの前には空行を置く
+func (r *Renderer) writeItemBlocks(out *bytes.Buffer, blocks []ast.Block) {
+	sub := *r
+	if sub.Wrap > 2 {
+		sub.Wrap -= 2
+	}
+
+	for i, block := range blocks {
+		if _, ok := block.(*ast.DiscList); !ok || i > 0 {
+			out.WriteString("\n")
+		}
+
+		var body bytes.Buffer
+		sub.writeBlock(&body, block)
+		for _, line := range strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n") {
+			if line != "" {
+				out.WriteString("  " + line)
+			}
+			out.WriteString("\n")
+		}
+	}
+}
+
+// コードブロックを罫線で囲んで出力する
+// タイトルか言語が指定されている場合は上の罫線に表示する
+// 罫線の位置を揃えるため、タブは空白に展開し、全角文字は2桁として幅を数える
+func (r *Renderer) writeCodeBlock(out *bytes.Buffer, block *ast.CodeBlock) {
+	var code strings.Builder
+	for _, inline := range block.Contents {
+		code.WriteString(ast.PlainText(inline))
+	}
+	lines := strings.Split(strings.TrimSuffix(sanitize(code.String()), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+
+	// 上の罫線にはタイトルか言語を表示する
+	lang := block.Attributes["title"]
+	if lang == "" && block.Lang != nil {
+		lang = ast.PlainText(block.Lang)
+	}
+	lang = strings.TrimSpace(expandTabs(sanitize(lang)))
+
+	width := 0
+	for _, line := range lines {
+		if n := eastasian.Width(line); n > width {
+			width = n
+		}
+	}
+	if n := eastasian.Width(lang) + 2; lang != "" && n > width {
+		width = n
+	}
+
+	border := []string{faint}
+
+	top := "┌" + strings.Repeat("─", width+2) + "┐"
+	if lang != "" {
+		top = "┌─ " + lang + " " + strings.Repeat("─", width-eastasian.Width(lang)-1) + "┐"
+	}
+	out.WriteString(r.style(top, border))
+	out.WriteString("\n")
+
+	for _, line := range lines {
+		out.WriteString(r.style("│", border))
+		out.WriteString(" ")
+		out.WriteString(line)
+		out.WriteString(strings.Repeat(" ", width-eastasian.Width(line)+1))
+		out.WriteString(r.style("│", border))
+		out.WriteString("\n")
+	}
+
+	out.WriteString(r.style("└"+strings.Repeat("─", width+2)+"┘", border))
+	out.WriteString("\n")
+}
+
+// タブを展開する幅
+const tabWidth = 4
+
+// 文書中の制御文字を取り除く
+// 信頼できない文書がエスケープシーケンスで端末を操作できないように、改行とタブ以外は出力しない
+func sanitize(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// タブを次のtabWidthの倍数の桁まで空白に展開する
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var out strings.Builder
+	n := 0
+	for _, ch := range line {
+		if ch == '\t' {
+			spaces := tabWidth - n%tabWidth
+			out.WriteString(strings.Repeat(" ", spaces))
+			n += spaces
+			continue
+		}
+		out.WriteRune(ch)
+		n += eastasian.RuneWidth(ch)
+	}
+	return out.String()
+}
+
+// 装飾が同じテキストの断片
+type span struct {
+	text  string
+	style []string
+	brk   bool // 強制改行
+}
+
+// 表示される1つの単語
+type word []span
+
+func (w word) width() int {
+	n := 0
+	for _, s := range w {
+		n += eastasian.Width(s.text)
+	}
+	return n
+}
+
+// インライン要素の列を装飾ごとの断片にする
+func spans(inlines []ast.Inline, style []string) []span {
+	var list []span
+
+	for _, inline := range inlines {
+		switch inline := inline.(type) {
+		case *ast.Emphasis:
+			inner := style
+			if inline.Level >= 2 {
+				inner = with(inner, bold)
+			}
+			if inline.Level != 2 {
+				inner = with(inner, italic)
+			}
+			list = append(list, spans(inline.Contents, inner)...)
+		case *ast.Strikethrough:
+			list = append(list, spans(inline.Contents, with(style, strikethrough))...)
+		case *ast.InlineCode:
+			list = append(list, span{text: sanitize(ast.PlainText(inline)), style: with(style, cyan)})
+		case *ast.FootnoteReference:
+			list = append(list, span{text: "[" + strconv.Itoa(inline.Index) + "]", style: with(style, faint)})
+		case *ast.SoftBreak:
+			list = append(list, span{text: " ", style: style})
+		case *ast.HardBreak:
+			list = append(list, span{brk: true})
+		case *ast.Text:
+			list = append(list, span{text: sanitize(inline.Content), style: style})
+		}
+	}
+
+	return list
+}
+
+// 装飾を追加した新しいスライスを返す
+func with(style []string, param string) []string {
+	return append(append([]string{}, style...), param)
+}
+
+// 断片を単語に分けて、単語単位で折り返す
+// 強制改行の位置では必ず改行し、widthより長い単語は分割しない
+func wrap(list []span, width int) [][]word {
+	var words []word
+	var current word
+	for _, s := range list {
+		if s.brk {
+			if len(current) > 0 {
+				words = append(words, current)
+				current = nil
+			}
+			// 強制改行はnilの単語で表す
+			words = append(words, nil)
+			continue
+		}
+
+		start := 0
+		for i, ch := range s.text {
+			if !unicode.IsSpace(ch) {
+				continue
+			}
+			if i > start {
+				current = append(current, span{text: s.text[start:i], style: s.style})
+			}
+			if len(current) > 0 {
+				words = append(words, current)
+				current = nil
+			}
+			start = i + utf8.RuneLen(ch)
+		}
+		if start < len(s.text) {
+			current = append(current, span{text: s.text[start:], style: s.style})
+		}
+	}
+	if len(current) > 0 {
+		words = append(words, current)
+	}
+
+	var lines [][]word
+	var line []word
+	n := 0
+	for _, w := range words {
+		if w == nil {
+			lines = append(lines, line)
+			line = nil
+			n = 0
+			continue
+		}
+
+		wn := w.width()
+		if n > 0 && width > 0 && n+1+wn > width {
+			lines = append(lines, line)
+			line = nil
+			n = 0
+		}
+		if n > 0 {
+			n++
+		}
+		line = append(line, w)
+		n += wn
+	}
+	lines = append(lines, line)
+
+	return lines
+}
+
+func maxWidth(lines [][]word) int {
+	width := 0
+	for _, line := range lines {
+		n := 0
+		for i, w := range line {
+			if i > 0 {
+				n++
+			}
+			n += w.width()
+		}
+		if n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+// 行を出力する
+// 1行目の先頭にはfirst、2行目以降の先頭にはrestを付ける
+func (r *Renderer) writeLines(out *bytes.Buffer, lines [][]word, first, rest string) {
+	for i, line := range lines {
+		if i == 0 {
+			out.WriteString(first)
+		} else {
+			out.WriteString(rest)
+		}
+
+		for j, w := range line {
+			if j > 0 {
+				out.WriteString(" ")
+			}
+			for _, s := range w {
+				out.WriteString(r.style(s.text, s.style))
+			}
+		}
+		out.WriteString("\n")
+	}
+}
+
+// テキストを装飾する
+func (r *Renderer) style(text string, style []string) string {
+	if r.NoColor || len(style) == 0 {
+		return text
+	}
+	return "\x1b[" + strings.Join(style, ";") + "m" + text + "\x1b[0m"
+}
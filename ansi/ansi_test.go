@@ -0,0 +1,99 @@
+package ansi
+
+import "testing"
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"# a *b*\n",
+			"\x1b[1;35ma\x1b[0m \x1b[1;35;3mb\x1b[0m\n",
+		},
+		{
+			"**a** ~~b~~ `c`\n",
+			"\x1b[1ma\x1b[0m \x1b[9mb\x1b[0m \x1b[36mc\x1b[0m\n",
+		},
+		{
+			"- ***a***\n",
+			"• \x1b[1;3ma\x1b[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Text(tt.input, &Renderer{})
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestTextNoColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"# Heading*2*\n## Sub\n",
+			"Heading2\n========\n\nSub\n---\n",
+		},
+		{
+			"```go\nfmt.Println()\n```\n",
+			"┌─ go ──────────┐\n│ fmt.Println() │\n└───────────────┘\n",
+		},
+		{
+			"```\na\n```\n",
+			"┌───┐\n│ a │\n└───┘\n",
+		},
+		{
+			"```\n日本語\nab\n```\n",
+			"┌────────┐\n│ 日本語 │\n│ ab     │\n└────────┘\n",
+		},
+		{
+			"```go {title=例}\nx\n```\n",
+			"┌─ 例 ─┐\n│ x    │\n└──────┘\n",
+		},
+		{
+			"```\nあ\tb\n```\n",
+			"┌───────┐\n│ あ  b │\n└───────┘\n",
+		},
+		{
+			"- a\n  - b\n\n      c\n",
+			"• a\n  • b\n\n    c\n",
+		},
+		{
+			"soft\nbreak  \nhard\n",
+			"soft break\nhard\n",
+		},
+		{
+			"```\na\tb\n\tc\n```\n",
+			"┌───────┐\n│ a   b │\n│     c │\n└───────┘\n",
+		},
+		{
+			"a\x1b]0;title\x07 `\x1b[2J`\n\n```\n\x1b[31mx\n```\n",
+			"a]0;title [2J\n\n┌───────┐\n│ [31mx │\n└───────┘\n",
+		},
+		{
+			"```go title=main.go\nx\n```\n",
+			"┌─ main.go ─┐\n│ x         │\n└───────────┘\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Text(tt.input, &Renderer{NoColor: true})
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestTextWrap(t *testing.T) {
+	input := "aaa **bbb ccc** ddd\n- eee fff ggg\n\n---\n"
+	expected := "aaa \x1b[1mbbb\x1b[0m\n\x1b[1mccc\x1b[0m ddd\n\n• eee fff\n  ggg\n\n\x1b[2m──────────\x1b[0m\n"
+
+	actual := Text(input, &Renderer{Wrap: 10})
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+}
@@ -25,7 +25,10 @@ type Inline interface {
 
 // ASTのルートノード
 type Document struct {
-	Blocks []Block
+	Blocks      []Block
+	FrontMatter map[string]string // 文書の先頭の---で囲まれたメタデータ
+	// 脚注の定義(参照された順、参照されていない定義は最後)
+	Footnotes []*Footnote
 }
 
 func (d *Document) TokenLiteral() string {
@@ -50,6 +53,7 @@ func (d *Document) String() string {
 type Heading struct {
 	Token    token.Token
 	Level    int
+	ID       string // アンカーとして使うid属性(空の場合は出力しない)
 	Contents []Inline
 }
 
@@ -62,6 +66,9 @@ func (h *Heading) String() string {
 
 	out.WriteString("<")
 	out.WriteString(htag)
+	if h.ID != "" {
+		out.WriteString(" id=\"" + h.ID + "\"")
+	}
 	out.WriteString(">")
 	for _, l := range h.Contents {
 		out.WriteString(l.String())
@@ -76,8 +83,9 @@ func (h *Heading) String() string {
 
 // Discリスト
 type DiscList struct {
-	Token token.Token
-	Lists [][]Inline
+	Token  token.Token
+	Lists  [][]Inline
+	Blocks [][]Block // 項目の続きのブロック(入れ子のリストや空行の後の字下げした内容、ない場合はnil)
 }
 
 func (d *DiscList) blockNode()           {}
@@ -88,11 +96,17 @@ func (d *DiscList) String() string {
 	out.WriteString("<p>\n")
 	out.WriteString("<ul>\n")
 
-	for _, l := range d.Lists {
+	for i, l := range d.Lists {
 		out.WriteString("<li>")
 		for _, l2 := range l {
 			out.WriteString(l2.String())
 		}
+		if blocks := d.ItemBlocks(i); len(blocks) > 0 {
+			out.WriteString("\n")
+			for _, b := range blocks {
+				out.WriteString(b.String())
+			}
+		}
 		out.WriteString("</li>\n")
 	}
 
@@ -103,6 +117,15 @@ func (d *DiscList) String() string {
 	return out.String()
 }
 
+// i番目の項目の続きのブロック
+// Blocksが項目より短い場合もnilを返す
+func (d *DiscList) ItemBlocks(i int) []Block {
+	if i >= len(d.Blocks) {
+		return nil
+	}
+	return d.Blocks[i]
+}
+
 // パラグラフ
 type Paragraph struct {
 	Token    token.Token
@@ -128,9 +151,11 @@ func (p *Paragraph) String() string {
 
 // コードブロック
 type CodeBlock struct {
-	Token    token.Token
-	Lang     Inline
-	Contents []Inline
+	Token      token.Token
+	Lang       Inline
+	Attributes map[string]string // 情報文字列で言語の後に指定した属性(```go {linenos=true})
+	Indented   bool              // フェンスではなく字下げによるコードブロック(言語と属性はない)
+	Contents   []Inline
 }
 
 func (c *CodeBlock) blockNode()           {}
@@ -139,9 +164,7 @@ func (c *CodeBlock) String() string {
 	var out bytes.Buffer
 
 	var lang string
-	if c.Lang.String() == "" {
-		lang = ""
-	} else {
+	if c.Lang != nil {
 		lang = c.Lang.String()
 	}
 
@@ -164,7 +187,7 @@ func (c *CodeBlock) String() string {
 
 // 強調
 type Emphasis struct {
-	Token    token.Token
+	Token    token.Token // Literalは開始側の区切り文字(*、__、_**など)
 	Level    int
 	Contents []Inline
 }
@@ -187,11 +210,11 @@ func (e *Emphasis) String() string {
 		}
 		out.WriteString("</strong>")
 	} else if e.Level == 3 {
-		out.WriteString("<strong><em>")
+		out.WriteString("<em><strong>")
 		for _, l := range e.Contents {
 			out.WriteString(l.String())
 		}
-		out.WriteString("</em></strong>")
+		out.WriteString("</strong></em>")
 	}
 
 	return out.String()
@@ -247,9 +270,75 @@ func (t *Text) inlineNode()          {}
 func (t *Text) TokenLiteral() string { return t.Token.Literal }
 func (t *Text) String() string       { return t.Content }
 
+// ソフト改行(パラグラフの途中の改行)
+type SoftBreak struct {
+	Token token.Token
+}
+
+func (s *SoftBreak) inlineNode()          {}
+func (s *SoftBreak) TokenLiteral() string { return s.Token.Literal }
+func (s *SoftBreak) String() string       { return "\n" }
+
+// 強制改行(行末の2つ以上の空白かバックスラッシュ)
+type HardBreak struct {
+	Token token.Token
+}
+
+func (h *HardBreak) inlineNode()          {}
+func (h *HardBreak) TokenLiteral() string { return h.Token.Literal }
+func (h *HardBreak) String() string       { return "<br/>\n" }
+
 // 水平線
-type HorizontalRule struct{}
+type HorizontalRule struct {
+	Token token.Token
+}
 
 func (h *HorizontalRule) blockNode()           {}
 func (h *HorizontalRule) TokenLiteral() string { return "" }
 func (h *HorizontalRule) String() string       { return "<hr/>\n" }
+
+// 脚注の定義([^label]: text)
+type Footnote struct {
+	Token  token.Token
+	Label  string
+	Index  int // 脚注の番号(参照されていない場合は0)
+	Blocks []Block
+}
+
+func (f *Footnote) TokenLiteral() string { return f.Token.Literal }
+func (f *Footnote) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("<li id=\"fn-" + strconv.Itoa(f.Index) + "\">\n")
+	for _, b := range f.Blocks {
+		out.WriteString(b.String())
+	}
+	out.WriteString("</li>\n")
+
+	return out.String()
+}
+
+// 脚注の参照([^label])
+type FootnoteReference struct {
+	Token token.Token
+	Label string
+	Index int // 参照する脚注の番号
+	Ref   int // 同じ脚注への何番目の参照か(1から)
+}
+
+func (f *FootnoteReference) inlineNode()          {}
+func (f *FootnoteReference) TokenLiteral() string { return f.Token.Literal }
+func (f *FootnoteReference) String() string {
+	n := strconv.Itoa(f.Index)
+	return "<sup class=\"footnote-ref\"><a href=\"#fn-" + n + "\" id=\"" + f.RefID() + "\">" + n + "</a></sup>"
+}
+
+// 参照元に戻るリンクのためのid
+// 同じ脚注への2番目以降の参照には番号を付ける
+func (f *FootnoteReference) RefID() string {
+	id := "fnref-" + strconv.Itoa(f.Index)
+	if f.Ref > 1 {
+		id += "-" + strconv.Itoa(f.Ref)
+	}
+	return id
+}
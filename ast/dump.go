@@ -0,0 +1,91 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"godown/token"
+	"sort"
+	"strings"
+)
+
+// ノードを1行ずつ字下げした木として表示する
+// 各行には型名、属性、トークンの位置(行:列)を含める
+func Dump(node Node) string {
+	var out bytes.Buffer
+	dump(&out, node, 0)
+	return out.String()
+}
+
+func dump(out *bytes.Buffer, node Node, depth int) {
+	line := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+
+	switch node := node.(type) {
+	case *Heading:
+		line += fmt.Sprintf(" level=%d", node.Level)
+		if node.ID != "" {
+			line += fmt.Sprintf(" id=%q", node.ID)
+		}
+	case *CodeBlock:
+		if node.Indented {
+			line += " indented"
+		}
+		keys := make([]string, 0, len(node.Attributes))
+		for key := range node.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			line += fmt.Sprintf(" %s=%q", key, node.Attributes[key])
+		}
+	case *Emphasis:
+		line += fmt.Sprintf(" level=%d", node.Level)
+	case *Footnote:
+		line += fmt.Sprintf(" label=%q index=%d", node.Label, node.Index)
+	case *FootnoteReference:
+		line += fmt.Sprintf(" label=%q index=%d", node.Label, node.Index)
+	case *Text:
+		line += fmt.Sprintf(" %q", node.Content)
+	}
+
+	if tok := nodeToken(node); tok.Line > 0 {
+		line += fmt.Sprintf(" @%d:%d", tok.Line, tok.Column)
+	}
+
+	out.WriteString(strings.Repeat("  ", depth) + line + "\n")
+
+	for _, child := range Children(node) {
+		dump(out, child, depth+1)
+	}
+}
+
+func nodeToken(node Node) token.Token {
+	switch node := node.(type) {
+	case *Heading:
+		return node.Token
+	case *DiscList:
+		return node.Token
+	case *Paragraph:
+		return node.Token
+	case *CodeBlock:
+		return node.Token
+	case *HorizontalRule:
+		return node.Token
+	case *Emphasis:
+		return node.Token
+	case *InlineCode:
+		return node.Token
+	case *Strikethrough:
+		return node.Token
+	case *Footnote:
+		return node.Token
+	case *FootnoteReference:
+		return node.Token
+	case *SoftBreak:
+		return node.Token
+	case *HardBreak:
+		return node.Token
+	case *Text:
+		return node.Token
+	}
+	return token.Token{}
+}
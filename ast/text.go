@@ -0,0 +1,47 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// ノードに含まれるテキストを装飾を除いて連結する
+// 改行は空白にする
+func PlainText(node Node) string {
+	var out bytes.Buffer
+
+	Inspect(node, func(n Node) bool {
+		switch n := n.(type) {
+		case *Text:
+			out.WriteString(n.Content)
+		case *SoftBreak, *HardBreak:
+			out.WriteString(" ")
+		}
+		return true
+	})
+
+	return out.String()
+}
+
+// 見出しのテキストからアンカーに使うidを生成する
+// 英数字以外の文字は取り除き、空白とハイフンは-にまとめる
+func Slug(s string) string {
+	var out strings.Builder
+
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			out.WriteRune(r)
+			hyphen = false
+		case unicode.IsSpace(r) || r == '-':
+			if !hyphen && out.Len() > 0 {
+				out.WriteRune('-')
+				hyphen = true
+			}
+		}
+	}
+
+	return strings.TrimSuffix(out.String(), "-")
+}
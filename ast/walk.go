@@ -0,0 +1,63 @@
+package ast
+
+// ASTを深さ優先で走査する
+// fがfalseを返した場合、そのノードの子要素は走査しない
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	for _, child := range Children(node) {
+		Inspect(child, f)
+	}
+}
+
+// ノードの直接の子要素を返す
+func Children(node Node) []Node {
+	var children []Node
+
+	switch node := node.(type) {
+	case *Document:
+		for _, b := range node.Blocks {
+			children = append(children, b)
+		}
+		for _, f := range node.Footnotes {
+			children = append(children, f)
+		}
+	case *Footnote:
+		for _, b := range node.Blocks {
+			children = append(children, b)
+		}
+	case *Heading:
+		children = appendInlines(children, node.Contents)
+	case *DiscList:
+		for i, l := range node.Lists {
+			children = appendInlines(children, l)
+			for _, b := range node.ItemBlocks(i) {
+				children = append(children, b)
+			}
+		}
+	case *Paragraph:
+		children = appendInlines(children, node.Contents)
+	case *CodeBlock:
+		if node.Lang != nil {
+			children = append(children, node.Lang)
+		}
+		children = appendInlines(children, node.Contents)
+	case *Emphasis:
+		children = appendInlines(children, node.Contents)
+	case *InlineCode:
+		children = appendInlines(children, node.Contents)
+	case *Strikethrough:
+		children = appendInlines(children, node.Contents)
+	}
+
+	return children
+}
+
+func appendInlines(nodes []Node, inlines []Inline) []Node {
+	for _, i := range inlines {
+		nodes = append(nodes, i)
+	}
+	return nodes
+}
@@ -0,0 +1,350 @@
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"godown/ast"
+	"godown/token"
+)
+
+// JSONスキーマのバージョン
+// 互換性のない変更をした場合に上げる
+const Version = 1
+
+// JSONで表した文書
+type Document struct {
+	Version int `json:"version"`
+	// フロントマターがない場合はnull、空の場合は{}
+	FrontMatter map[string]string `json:"frontMatter"`
+	Blocks      []*Node           `json:"blocks"`
+	Footnotes   []*Node           `json:"footnotes,omitempty"`
+}
+
+// JSONで表したノード
+// Typeはast.Headingなどの型名で、ノードの種類ごとに使うフィールドが異なる
+//   - Heading: level, id, children
+//   - DiscList: items, itemBlocks(項目の続きのブロック)
+//   - Paragraph, InlineCode, Strikethrough: children
+//   - CodeBlock: lang, attributes, indented, children
+//   - Emphasis: level, children
+//   - Text: content
+//   - HorizontalRule, SoftBreak, HardBreak: なし
+//   - Footnote: label, index, children
+//   - FootnoteReference: label, index, ref
+type Node struct {
+	Type       string            `json:"type"`
+	Token      *Token            `json:"token,omitempty"`
+	Level      int               `json:"level,omitempty"`
+	ID         string            `json:"id,omitempty"`
+	Label      string            `json:"label,omitempty"`
+	Index      int               `json:"index,omitempty"`
+	Ref        int               `json:"ref,omitempty"`
+	Content    string            `json:"content,omitempty"`
+	Lang       *Node             `json:"lang,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Indented   bool              `json:"indented,omitempty"`
+	Items      [][]*Node         `json:"items,omitempty"`
+	ItemBlocks [][]*Node         `json:"itemBlocks,omitempty"`
+	Children   []*Node           `json:"children,omitempty"`
+}
+
+// JSONで表したトークン
+type Token struct {
+	Type    string `json:"type"`
+	Literal string `json:"literal"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// ASTをJSONにする
+func Marshal(doc *ast.Document) ([]byte, error) {
+	d, err := FromAST(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// JSONからASTを復元する
+func Unmarshal(data []byte) (*ast.Document, error) {
+	var d Document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return d.AST()
+}
+
+// ASTをJSONで表した文書に変換する
+func FromAST(doc *ast.Document) (*Document, error) {
+	d := &Document{Version: Version, FrontMatter: doc.FrontMatter, Blocks: []*Node{}}
+
+	for _, block := range doc.Blocks {
+		n, err := fromNode(block)
+		if err != nil {
+			return nil, err
+		}
+		d.Blocks = append(d.Blocks, n)
+	}
+
+	for _, footnote := range doc.Footnotes {
+		n, err := fromNode(footnote)
+		if err != nil {
+			return nil, err
+		}
+		d.Footnotes = append(d.Footnotes, n)
+	}
+
+	return d, nil
+}
+
+func fromNode(node ast.Node) (*Node, error) {
+	var n *Node
+	var err error
+
+	switch node := node.(type) {
+	case *ast.Heading:
+		n = &Node{Type: "Heading", Token: fromToken(node.Token), Level: node.Level, ID: node.ID}
+		n.Children, err = fromInlines(node.Contents)
+	case *ast.DiscList:
+		n = &Node{Type: "DiscList", Token: fromToken(node.Token)}
+		for _, list := range node.Lists {
+			item, err := fromInlines(list)
+			if err != nil {
+				return nil, err
+			}
+			n.Items = append(n.Items, item)
+		}
+		for _, blocks := range node.Blocks {
+			var item []*Node
+			for _, block := range blocks {
+				child, err := fromNode(block)
+				if err != nil {
+					return nil, err
+				}
+				item = append(item, child)
+			}
+			n.ItemBlocks = append(n.ItemBlocks, item)
+		}
+	case *ast.Paragraph:
+		n = &Node{Type: "Paragraph", Token: fromToken(node.Token)}
+		n.Children, err = fromInlines(node.Contents)
+	case *ast.CodeBlock:
+		n = &Node{Type: "CodeBlock", Token: fromToken(node.Token), Attributes: node.Attributes, Indented: node.Indented}
+		if node.Lang != nil {
+			if n.Lang, err = fromNode(node.Lang); err != nil {
+				return nil, err
+			}
+		}
+		n.Children, err = fromInlines(node.Contents)
+	case *ast.HorizontalRule:
+		n = &Node{Type: "HorizontalRule", Token: fromToken(node.Token)}
+	case *ast.Emphasis:
+		n = &Node{Type: "Emphasis", Token: fromToken(node.Token), Level: node.Level}
+		n.Children, err = fromInlines(node.Contents)
+	case *ast.InlineCode:
+		n = &Node{Type: "InlineCode", Token: fromToken(node.Token)}
+		n.Children, err = fromInlines(node.Contents)
+	case *ast.Strikethrough:
+		n = &Node{Type: "Strikethrough", Token: fromToken(node.Token)}
+		n.Children, err = fromInlines(node.Contents)
+	case *ast.Footnote:
+		n = &Node{Type: "Footnote", Token: fromToken(node.Token), Label: node.Label, Index: node.Index}
+		for _, block := range node.Blocks {
+			child, err := fromNode(block)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, child)
+		}
+	case *ast.FootnoteReference:
+		n = &Node{Type: "FootnoteReference", Token: fromToken(node.Token), Label: node.Label, Index: node.Index, Ref: node.Ref}
+	case *ast.SoftBreak:
+		n = &Node{Type: "SoftBreak", Token: fromToken(node.Token)}
+	case *ast.HardBreak:
+		n = &Node{Type: "HardBreak", Token: fromToken(node.Token)}
+	case *ast.Text:
+		n = &Node{Type: "Text", Token: fromToken(node.Token), Content: node.Content}
+	default:
+		return nil, fmt.Errorf("astjson: unsupported node %T", node)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func fromInlines(inlines []ast.Inline) ([]*Node, error) {
+	var nodes []*Node
+	for _, inline := range inlines {
+		n, err := fromNode(inline)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// 位置情報のないトークンは省略する
+func fromToken(tok token.Token) *Token {
+	if tok == (token.Token{}) {
+		return nil
+	}
+	return &Token{Type: string(tok.Type), Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+// ASTに変換する
+// パーサと同じく、文書と脚注のBlocksは空の場合も非nil、子要素のスライスは空の場合nilになる
+func (d *Document) AST() (*ast.Document, error) {
+	if d.Version != Version {
+		return nil, fmt.Errorf("astjson: unsupported version %d", d.Version)
+	}
+
+	doc := &ast.Document{FrontMatter: d.FrontMatter, Blocks: []ast.Block{}}
+	for _, n := range d.Blocks {
+		b, err := block(n)
+		if err != nil {
+			return nil, err
+		}
+		doc.Blocks = append(doc.Blocks, b)
+	}
+
+	for _, n := range d.Footnotes {
+		node, err := n.node()
+		if err != nil {
+			return nil, err
+		}
+		footnote, ok := node.(*ast.Footnote)
+		if !ok {
+			return nil, fmt.Errorf("astjson: %s is not a footnote", n.Type)
+		}
+		doc.Footnotes = append(doc.Footnotes, footnote)
+	}
+
+	return doc, nil
+}
+
+func (n *Node) node() (ast.Node, error) {
+	if n == nil {
+		return nil, fmt.Errorf("astjson: null node")
+	}
+
+	tok := n.Token.token()
+
+	switch n.Type {
+	case "Heading":
+		contents, err := inlines(n.Children)
+		return &ast.Heading{Token: tok, Level: n.Level, ID: n.ID, Contents: contents}, err
+	case "DiscList":
+		list := &ast.DiscList{Token: tok}
+		for _, item := range n.Items {
+			contents, err := inlines(item)
+			if err != nil {
+				return nil, err
+			}
+			list.Lists = append(list.Lists, contents)
+		}
+		for _, item := range n.ItemBlocks {
+			var blocks []ast.Block
+			for _, child := range item {
+				b, err := block(child)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, b)
+			}
+			list.Blocks = append(list.Blocks, blocks)
+		}
+		return list, nil
+	case "Paragraph":
+		contents, err := inlines(n.Children)
+		return &ast.Paragraph{Token: tok, Contents: contents}, err
+	case "CodeBlock":
+		block := &ast.CodeBlock{Token: tok, Attributes: n.Attributes, Indented: n.Indented}
+		if n.Lang != nil {
+			lang, err := inline(n.Lang)
+			if err != nil {
+				return nil, err
+			}
+			block.Lang = lang
+		}
+		contents, err := inlines(n.Children)
+		block.Contents = contents
+		return block, err
+	case "HorizontalRule":
+		return &ast.HorizontalRule{Token: tok}, nil
+	case "Emphasis":
+		contents, err := inlines(n.Children)
+		return &ast.Emphasis{Token: tok, Level: n.Level, Contents: contents}, err
+	case "InlineCode":
+		contents, err := inlines(n.Children)
+		return &ast.InlineCode{Token: tok, Contents: contents}, err
+	case "Strikethrough":
+		contents, err := inlines(n.Children)
+		return &ast.Strikethrough{Token: tok, Contents: contents}, err
+	case "Footnote":
+		footnote := &ast.Footnote{Token: tok, Label: n.Label, Index: n.Index, Blocks: []ast.Block{}}
+		for _, child := range n.Children {
+			b, err := block(child)
+			if err != nil {
+				return nil, err
+			}
+			footnote.Blocks = append(footnote.Blocks, b)
+		}
+		return footnote, nil
+	case "FootnoteReference":
+		return &ast.FootnoteReference{Token: tok, Label: n.Label, Index: n.Index, Ref: n.Ref}, nil
+	case "SoftBreak":
+		return &ast.SoftBreak{Token: tok}, nil
+	case "HardBreak":
+		return &ast.HardBreak{Token: tok}, nil
+	case "Text":
+		return &ast.Text{Token: tok, Content: n.Content}, nil
+	}
+
+	return nil, fmt.Errorf("astjson: unknown node type %q", n.Type)
+}
+
+func block(n *Node) (ast.Block, error) {
+	node, err := n.node()
+	if err != nil {
+		return nil, err
+	}
+	b, ok := node.(ast.Block)
+	if !ok {
+		return nil, fmt.Errorf("astjson: %s is not a block", n.Type)
+	}
+	return b, nil
+}
+
+func inline(n *Node) (ast.Inline, error) {
+	node, err := n.node()
+	if err != nil {
+		return nil, err
+	}
+	i, ok := node.(ast.Inline)
+	if !ok {
+		return nil, fmt.Errorf("astjson: %s is not an inline", n.Type)
+	}
+	return i, nil
+}
+
+func inlines(nodes []*Node) ([]ast.Inline, error) {
+	var list []ast.Inline
+	for _, n := range nodes {
+		i, err := inline(n)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	return list, nil
+}
+
+func (t *Token) token() token.Token {
+	if t == nil {
+		return token.Token{}
+	}
+	return token.Token{Type: token.TokenType(t.Type), Literal: t.Literal, Line: t.Line, Column: t.Column}
+}
@@ -0,0 +1,108 @@
+package astjson
+
+import (
+	"godown/ast"
+	"godown/lexer"
+	"godown/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse(input string) *ast.Document {
+	l := lexer.New(input)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions|parser.FrontMatter|parser.HeadingIDs)
+	return p.ParseDocument()
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"---\ntitle: \"a: b\"\n---\n# *text*1\n## M2\n*2text* **b** ***c*** ~~d~~ `e`\n",
+		"- a\n- *b*\n\n---\n\n```go\nfunc main() {}\n```\n```\ncode\n```\n",
+		"---\n---\ntext\n",
+		"```go {linenos=true hl=\"1-2\"}\na\n```\n",
+		"text\n\n    code\n\t\tindented\n",
+		"soft\nbreak  \nhard\\\nbreak\n",
+		"a[^1] *b[^x]*\n\n[^x]: x[^1]\n[^1]: one\n\n    two\n[^unused]: u\n",
+		"a[^a]\n\n[^a]: \n",
+		"- a\n  - b\n- c\n- d\n\n      e[^1]\n\n[^1]: f\n",
+	}
+
+	for _, input := range inputs {
+		doc := parse(input)
+
+		data, err := Marshal(doc)
+		if err != nil {
+			t.Fatalf("input=%q: %v", input, err)
+		}
+
+		actual, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("input=%q: %v", input, err)
+		}
+
+		if !reflect.DeepEqual(actual, doc) {
+			t.Errorf("input=%q round trip changed the AST. json=%s", input, data)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(parse("# a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "version": 1,
+  "frontMatter": null,
+  "blocks": [
+    {
+      "type": "Heading",
+      "token": {
+        "type": "#",
+        "literal": "#",
+        "line": 1,
+        "column": 1
+      },
+      "level": 1,
+      "id": "a",
+      "children": [
+        {
+          "type": "Text",
+          "token": {
+            "type": "TEXT",
+            "literal": "a",
+            "line": 1,
+            "column": 3
+          },
+          "content": "a"
+        }
+      ]
+    }
+  ]
+}`
+	if string(data) != expected {
+		t.Errorf("wrong. expected=%s, got=%s", expected, data)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"version": 2, "blocks": []}`, "unsupported version 2"},
+		{`{"version": 1, "blocks": [{"type": "Table"}]}`, `unknown node type "Table"`},
+		{`{"version": 1, "blocks": [{"type": "Text"}]}`, "Text is not a block"},
+		{`{"version": 1, "blocks": [{"type": "Paragraph", "children": [{"type": "HorizontalRule"}]}]}`, "HorizontalRule is not an inline"},
+	}
+
+	for _, tt := range tests {
+		_, err := Unmarshal([]byte(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("input=%q wrong error. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
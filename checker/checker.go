@@ -0,0 +1,132 @@
+package checker
+
+import (
+	"errors"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"godown/ast"
+	"godown/lexer"
+	gparser "godown/parser"
+	"strconv"
+	"strings"
+)
+
+// 検査で見つかった構文エラー
+type Error struct {
+	Line    int    // Markdownの行番号
+	Column  int    // Markdownの列番号
+	Message string // go/parserのエラーメッセージ
+}
+
+func (e Error) Error() string {
+	return strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column) + ": " + e.Message
+}
+
+// Markdown文書のGoのコードブロックを検査する
+func CheckSource(src string) []Error {
+	l := lexer.New(src)
+	p := gparser.NewWithExtensions(l, gparser.CommonExtensions)
+	return Check(p.ParseDocument())
+}
+
+// 言語がgoのコードブロックを構文解析し、コードブロックごとに最初のエラーを返す
+// 属性check=falseを指定したコードブロックは検査しない
+func Check(doc *ast.Document) []Error {
+	var errs []Error
+
+	ast.Inspect(doc, func(node ast.Node) bool {
+		block, ok := node.(*ast.CodeBlock)
+		if !ok {
+			return true
+		}
+		if block.Lang == nil || !isGo(block.Lang.String()) {
+			return false
+		}
+		if check, err := strconv.ParseBool(block.Attributes["check"]); err == nil && !check {
+			return false
+		}
+
+		var code strings.Builder
+		for _, c := range block.Contents {
+			code.WriteString(c.String())
+		}
+
+		if e := parse(code.String()); e != nil {
+			// 断片を囲むために付け加えた}の位置のエラーは閉じるフェンスの行とする
+			lines := strings.Count(strings.TrimSuffix(code.String(), "\n"), "\n") + 1
+			if e.Line > lines {
+				e.Line, e.Column = lines+1, 1
+			}
+			// コードはフェンスの次の行から始まる
+			e.Line += block.Token.Line
+			errs = append(errs, *e)
+		}
+		return false
+	})
+
+	return errs
+}
+
+func isGo(lang string) bool {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	return lang == "go" || lang == "golang"
+}
+
+// 宣言と文の断片を解析するためにコードの前に付ける文字列
+// 改行を含まないため、行番号は変わらない
+const (
+	declPrefix = "package p;"
+	stmtPrefix = "package p; func _() {"
+)
+
+// ソースファイル、宣言の列、文の列の順に解析を試みる(gofmtと同じ方法)
+// 位置はコードの先頭を1行1列とする
+func parse(code string) *Error {
+	err := parseFile(code)
+	if err == nil {
+		return nil
+	}
+	if !strings.Contains(err.Error(), "expected 'package'") {
+		return firstError(err, "")
+	}
+
+	err = parseFile(declPrefix + code)
+	if err == nil {
+		return nil
+	}
+	if !strings.Contains(err.Error(), "expected declaration") {
+		return firstError(err, declPrefix)
+	}
+
+	err = parseFile(stmtPrefix + code + "\n}")
+	if err == nil {
+		return nil
+	}
+	return firstError(err, stmtPrefix)
+}
+
+func parseFile(src string) error {
+	_, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	return err
+}
+
+// go/parserの最初のエラーを変換する
+// 1行目の列は付け加えた文字列の分だけずらす
+func firstError(err error, prefix string) *Error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return &Error{Line: 1, Column: 1, Message: err.Error()}
+	}
+
+	e := list[0]
+	column := e.Pos.Column
+	if e.Pos.Line == 1 {
+		column -= len(prefix)
+		if column < 1 {
+			column = 1
+		}
+	}
+
+	return &Error{Line: e.Pos.Line, Column: column, Message: e.Msg}
+}
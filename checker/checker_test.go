@@ -0,0 +1,31 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"```go\npackage main\n\nfunc main() {}\n```\n", nil},
+		{"```go\nfunc f() int { return 1 }\n```\n", nil},
+		{"```go\nx := 1\nfmt.Println(x)\n```\n", nil},
+		{"```go\npackage main\nfunc main() {\n```\n", []string{"3:15: expected '}', found 'EOF'"}},
+		{"# a\n\n```go\nx := \n```\n", []string{"5:1: expected operand, found '}'"}},
+		{"```go\nfunc f() {\n\tx = = 1\n}\n```\n", []string{"3:6: expected operand, found '='"}},
+		{"```go\nx := (1\n```\n```rust\nlet x = (1;\n```\n```go check=false\nx := (\n```\n", []string{"2:8: expected ')', found newline"}},
+	}
+
+	for _, tt := range tests {
+		var actual []string
+		for _, e := range CheckSource(tt.input) {
+			actual = append(actual, e.Error())
+		}
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"godown/ast"
+	"godown/astjson"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"os"
+)
+
+// ASTを表示する
+func runAST(args []string) error {
+	fs := flag.NewFlagSet("ast", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown ast [flags] [file]")
+		fs.PrintDefaults()
+	}
+	asJSON := fs.Bool("json", false, "output the AST as JSON")
+	fs.Parse(args)
+
+	var src []byte
+	var err error
+	switch fs.NArg() {
+	case 0:
+		src, err = io.ReadAll(os.Stdin)
+	case 1:
+		src, err = os.ReadFile(fs.Arg(0))
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		return err
+	}
+
+	l := lexer.New(string(src))
+	p := parser.NewWithExtensions(l, parser.CommonExtensions|parser.FrontMatter)
+	document := p.ParseDocument()
+
+	if *asJSON {
+		data, err := astjson.Marshal(document)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Print(ast.Dump(document))
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"godown/converter"
+	"os"
+)
+
+// ディレクトリ以下のMarkdownを一括で変換する
+func runBatch(args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown batch [flags] <input dir> <output dir>")
+		fs.PrintDefaults()
+	}
+	options := optionFlags(fs)
+	workers := fs.Int("j", 0, "number of files converted in parallel (0 means number of CPUs)")
+	fs.Parse(args)
+	opts := options()
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	report, err := converter.ConvertDir(context.Background(), fs.Arg(0), fs.Arg(1),
+		converter.BatchOptions{Options: opts, Workers: *workers})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range report.Errors {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	fmt.Fprintf(os.Stderr, "converted %d, copied %d, failed %d\n",
+		len(report.Converted), len(report.Copied), len(report.Errors))
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d files failed", len(report.Errors))
+	}
+
+	return nil
+}
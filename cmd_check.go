@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"godown/checker"
+	"io"
+	"os"
+)
+
+// Markdown中のGoのコードブロックの構文を検査する
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown check [file ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	count := 0
+	report := func(name, src string) {
+		for _, e := range checker.CheckSource(src) {
+			fmt.Printf("%s:%s\n", name, e)
+			count++
+		}
+	}
+
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		report("<stdin>", string(src))
+	}
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		report(name, string(src))
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%d code block(s) with syntax errors", count)
+	}
+
+	return nil
+}
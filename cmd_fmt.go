@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"godown/diff"
+	"godown/markdown"
+	"io"
+	"os"
+)
+
+// Markdownを正規化した形式に整形する
+func runFmt(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown fmt [flags] [file ...]")
+		fs.PrintDefaults()
+	}
+	write := fs.Bool("w", false, "write result to the source file instead of stdout")
+	showDiff := fs.Bool("d", false, "display diffs instead of rewriting files")
+	wrap := fs.Int("wrap", 0, "wrap paragraphs at this width (0 means no wrapping)")
+	fs.Parse(args)
+
+	r := &markdown.Renderer{Wrap: *wrap}
+
+	if fs.NArg() == 0 {
+		if *write {
+			return errors.New("cannot use -w with standard input")
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return formatFile("<stdin>", string(src), r, false, *showDiff)
+	}
+
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := formatFile(name, string(src), r, *write, *showDiff); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatFile(name, src string, r *markdown.Renderer, write, showDiff bool) error {
+	formatted := markdown.Format(src, r)
+
+	if showDiff {
+		fmt.Print(diff.Unified("a/"+name, "b/"+name, src, formatted))
+	}
+
+	if write {
+		if formatted == src {
+			return nil
+		}
+		// 元のファイルのパーミッションを保つ
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, []byte(formatted), info.Mode().Perm())
+	}
+
+	if !showDiff {
+		fmt.Print(formatted)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"godown/lint"
+	"io"
+	"os"
+)
+
+// 設定ファイルが指定されていない場合に読み込むファイル
+const defaultLintConfig = ".godownlint.json"
+
+// Markdownを検査して問題を報告する
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown lint [flags] [file ...]")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nrules:")
+		for _, r := range lint.Rules() {
+			fmt.Fprintf(fs.Output(), "  %-26s %s\n", r.Name(), r.Description())
+		}
+	}
+	config := fs.String("config", "", "JSON config `file` (default "+defaultLintConfig+" if it exists)")
+	asJSON := fs.Bool("json", false, "report problems as JSON")
+	fs.Parse(args)
+
+	cfg, err := lintConfig(*config)
+	if err != nil {
+		return err
+	}
+
+	problems := []lint.Problem{}
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		problems = appendProblems(problems, "<stdin>", string(src), cfg)
+	}
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		problems = appendProblems(problems, name, string(src), cfg)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(problems); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Printf("%s:%d:%d: %s: %s\n", p.File, p.Line, p.Column, p.Rule, p.Message)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) found", len(problems))
+	}
+
+	return nil
+}
+
+func lintConfig(name string) (*lint.Config, error) {
+	if name != "" {
+		return lint.LoadConfig(name)
+	}
+
+	cfg, err := lint.LoadConfig(defaultLintConfig)
+	if errors.Is(err, os.ErrNotExist) {
+		return lint.DefaultConfig(), nil
+	}
+	return cfg, err
+}
+
+func appendProblems(problems []lint.Problem, name, src string, cfg *lint.Config) []lint.Problem {
+	for _, p := range lint.Lint(src, cfg) {
+		p.File = name
+		problems = append(problems, p)
+	}
+	return problems
+}
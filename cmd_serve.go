@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"godown/server"
+	"net/http"
+	"os"
+)
+
+// ディレクトリ以下のMarkdownをライブリロード付きでプレビューする
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown serve [flags] [dir]")
+		fs.PrintDefaults()
+	}
+	options := optionFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "address to listen on (the default accepts local connections only)")
+	fs.Parse(args)
+	opts := options()
+
+	root := "."
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		root = fs.Arg(0)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	fmt.Fprintf(os.Stderr, "serving %s on http://%s/\n", root, *addr)
+
+	return http.ListenAndServe(*addr, server.New(root, opts))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"godown/converter"
+	"os"
+)
+
+// ディレクトリ以下のMarkdownからナビゲーション付きのサイトを生成する
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown site [flags] <input dir> <output dir>")
+		fs.PrintDefaults()
+	}
+	options := optionFlags(fs)
+	title := fs.String("title", "", "site title")
+	layout := fs.String("layout", "", "html/template `file` used as the page layout")
+	fs.Parse(args)
+	opts := options()
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	report, err := converter.BuildSite(context.Background(), fs.Arg(0), fs.Arg(1),
+		converter.SiteOptions{Options: opts, Title: *title, Layout: *layout})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range report.Errors {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	fmt.Fprintf(os.Stderr, "generated %d pages, copied %d, failed %d\n",
+		len(report.Converted), len(report.Copied), len(report.Errors))
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d files failed", len(report.Errors))
+	}
+
+	return nil
+}
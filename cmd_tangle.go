@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"godown/lexer"
+	"godown/parser"
+	"godown/tangle"
+	"os"
+)
+
+// コードブロックからソースファイルを組み立てて書き出す
+func runTangle(args []string) error {
+	fs := flag.NewFlagSet("tangle", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown tangle [flags] <file> ...")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("o", ".", "output `directory`")
+	dryRun := fs.Bool("n", false, "print the files that would be written without writing them")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	for _, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		l := lexer.New(string(src))
+		p := parser.NewWithExtensions(l, parser.CommonExtensions)
+
+		files, err := tangle.Tangle(p.ParseDocument())
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		for _, f := range files {
+			path, err := tangle.Resolve(*dir, f.Path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			fmt.Println(path)
+		}
+
+		if *dryRun {
+			continue
+		}
+		if err := tangle.Write(*dir, files); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
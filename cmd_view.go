@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"godown/ansi"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"os"
+	"strconv"
+)
+
+// 端末の幅がわからない場合の折り返し幅
+const defaultViewWidth = 80
+
+// Markdownを端末に表示する
+func runView(args []string) error {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown view [flags] [file ...]")
+		fs.PrintDefaults()
+	}
+	width := fs.Int("width", terminalWidth(), "wrap text at this width (0 means no wrapping)")
+	noColor := fs.Bool("no-color", false, "disable colors and styles")
+	fs.Parse(args)
+
+	r := &ansi.Renderer{Wrap: *width, NoColor: *noColor || !colorEnabled()}
+
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return view(string(src), r)
+	}
+
+	for i, name := range fs.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := view(string(src), r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func view(src string, r *ansi.Renderer) error {
+	l := lexer.New(src)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions)
+	document := p.ParseDocument()
+
+	var out bytes.Buffer
+	if err := r.Render(&out, document); err != nil {
+		return err
+	}
+
+	_, err := os.Stdout.Write(out.Bytes())
+	return err
+}
+
+// 環境変数COLUMNSから端末の幅を返す
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultViewWidth
+}
+
+// 標準出力が端末で、環境変数NO_COLORが設定されていない場合に色を使う
+func colorEnabled() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"godown/converter"
+	"godown/watcher"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// 入力を監視し、変更されたファイルを再変換する
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: godown watch [flags] <input file or dir> [output]")
+		fs.PrintDefaults()
+	}
+	options := optionFlags(fs)
+	interval := fs.Duration("interval", watcher.DefaultInterval, "polling interval")
+	debounce := fs.Duration("debounce", watcher.DefaultDebounce, "wait this long after the last change before rebuilding")
+	fs.Parse(args)
+	opts := options()
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	w, err := watcher.New(fs.Arg(0), fs.Arg(1), opts)
+	if err != nil {
+		return err
+	}
+	w.Interval = *interval
+	w.Debounce = *debounce
+	w.OnBuild = printSummary
+	w.OnError = func(e *converter.FileError) { fmt.Fprintln(os.Stderr, e) }
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "watching %s -> %s\n", w.Input, w.Output)
+
+	return w.Run(ctx)
+}
+
+// 再ビルドの結果を表示する
+func printSummary(s watcher.Summary) {
+	for _, e := range s.Errors {
+		fmt.Fprintln(os.Stderr, e)
+	}
+
+	msg := fmt.Sprintf("rebuilt %d", len(s.Rebuilt))
+	if len(s.Rebuilt) > 0 {
+		msg += " (" + strings.Join(s.Rebuilt, ", ") + ")"
+	}
+	if len(s.Removed) > 0 {
+		msg += fmt.Sprintf(", removed %d", len(s.Removed))
+	}
+	if len(s.Errors) > 0 {
+		msg += fmt.Sprintf(", failed %d", len(s.Errors))
+	}
+
+	fmt.Fprintf(os.Stderr, "%s in %s\n", msg, s.Duration.Round(time.Microsecond))
+}
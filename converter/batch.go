@@ -0,0 +1,211 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ディレクトリ一括変換の設定
+type BatchOptions struct {
+	Options
+
+	Workers int // 並列に変換するファイル数(0以下の場合はCPU数)
+}
+
+// ファイル単位のエラー
+type FileError struct {
+	Path string // 入力ディレクトリからの相対パス
+	Err  error
+}
+
+func (e *FileError) Error() string { return e.Path + ": " + e.Err.Error() }
+func (e *FileError) Unwrap() error { return e.Err }
+
+// 一括変換の結果
+type BatchReport struct {
+	Converted []string     // HTMLに変換したファイル
+	Copied    []string     // そのままコピーしたファイル
+	Errors    []*FileError // 失敗したファイル
+}
+
+// Markdownファイルかどうか
+func IsMarkdown(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".md")
+}
+
+// Markdownファイルのパスを出力するHTMLファイルのパスに変換する
+func HTMLPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".html"
+}
+
+// inDir以下のMarkdownファイルをHTMLに変換し、ディレクトリ構造を保ってoutDirに書き出す
+// Markdown以外のファイルはそのままコピーする
+// ファイル単位のエラーはBatchReport.Errorsに集め、走査自体の失敗のみerrorを返す
+func ConvertDir(ctx context.Context, inDir, outDir string, opts BatchOptions) (*BatchReport, error) {
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = filepath.WalkDir(inDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// 出力ディレクトリが入力ディレクトリの中にある場合は読み飛ばす
+			if abs, err := filepath.Abs(path); err == nil && abs == absOut {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(inDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts.RewriteLinks = true
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	report := &BatchReport{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range jobs {
+				src := filepath.Join(inDir, rel)
+
+				var err error
+				var converted bool
+				if IsMarkdown(rel) {
+					err = ConvertFile(ctx, src, filepath.Join(outDir, HTMLPath(rel)), opts.Options)
+					converted = true
+				} else {
+					err = CopyFile(src, filepath.Join(outDir, rel))
+				}
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					report.Errors = append(report.Errors, &FileError{Path: rel, Err: err})
+				case converted:
+					report.Converted = append(report.Converted, rel)
+				default:
+					report.Copied = append(report.Copied, rel)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, rel := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- rel
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Strings(report.Converted)
+	sort.Strings(report.Copied)
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Path < report.Errors[j].Path
+	})
+
+	return report, ctx.Err()
+}
+
+// srcのMarkdownファイルを変換してdstに書き出す
+func ConvertFile(ctx context.Context, src, dst string, opts Options) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var buf bytes.Buffer
+	if err := Convert(ctx, in, &buf, opts); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, buf.Bytes(), 0644)
+}
+
+// 相対パスで書かれた.mdへのリンク
+// //で始まるプロトコル相対URLは他のホストを指すため除く
+var mdLink = regexp.MustCompile(`(href=")(/?[^":?#/][^":?#]*)\.md((?:#[^"]*)?")`)
+
+// <pre>と<code>の要素
+var codeElement = regexp.MustCompile(`(?s)<pre\b.*?</pre>|<code\b.*?</code>`)
+
+// HTML中の.mdファイルへの相対リンクを.htmlへのリンクに書き換える
+// コードの例として書かれたリンクは書き換えないように、<pre>と<code>の中は除く
+func RewriteLinks(html []byte) []byte {
+	replacement := []byte("${1}${2}.html${3}")
+
+	var out []byte
+	last := 0
+	for _, loc := range codeElement.FindAllIndex(html, -1) {
+		out = append(out, mdLink.ReplaceAll(html[last:loc[0]], replacement)...)
+		out = append(out, html[loc[0]:loc[1]]...)
+		last = loc[1]
+	}
+
+	return append(out, mdLink.ReplaceAll(html[last:], replacement)...)
+}
+
+// srcのファイルをそのままdstにコピーする
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copy %s: %w", src, err)
+	}
+
+	return out.Close()
+}
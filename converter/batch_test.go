@@ -0,0 +1,98 @@
+package converter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConvertDir(t *testing.T) {
+	in := t.TempDir()
+	out := filepath.Join(t.TempDir(), "site")
+
+	writeFiles(t, in, map[string]string{
+		"index.md":     "# index\n",
+		"sub/page.md":  "<a href=\"../index.md\">index</a> <a href=\"https://example.com/x.md\">x</a>\n",
+		"sub/logo.png": "png",
+	})
+	if err := os.Symlink("missing", filepath.Join(in, "broken.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := BatchOptions{Options: DefaultOptions(), Workers: 2}
+	opts.Theme = testTheme
+
+	report, err := ConvertDir(context.Background(), in, out, opts)
+	if err != nil {
+		t.Fatalf("ConvertDir returned error: %v", err)
+	}
+
+	if expected := []string{"index.md", filepath.Join("sub", "page.md")}; !reflect.DeepEqual(report.Converted, expected) {
+		t.Errorf("converted wrong. expected=%v, got=%v", expected, report.Converted)
+	}
+	if expected := []string{filepath.Join("sub", "logo.png")}; !reflect.DeepEqual(report.Copied, expected) {
+		t.Errorf("copied wrong. expected=%v, got=%v", expected, report.Copied)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Path != "broken.md" {
+		t.Errorf("errors wrong. got=%v", report.Errors)
+	}
+
+	page, err := os.ReadFile(filepath.Join(out, "sub", "page.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `href="../index.html"`) {
+		t.Errorf("relative link was not rewritten")
+	}
+	if !strings.Contains(string(page), `href="https://example.com/x.md"`) {
+		t.Errorf("absolute link was rewritten")
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "index.html")); err != nil {
+		t.Errorf("index.html was not written: %v", err)
+	}
+	if logo, err := os.ReadFile(filepath.Join(out, "sub", "logo.png")); err != nil || string(logo) != "png" {
+		t.Errorf("asset was not copied: %q, %v", logo, err)
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<a href="a.md">`, `<a href="a.html">`},
+		{`<a href="dir/a.md#sec">`, `<a href="dir/a.html#sec">`},
+		{`<a href="http://example.com/a.md">`, `<a href="http://example.com/a.md">`},
+		{`<a href="a.mdx">`, `<a href="a.mdx">`},
+		{`a.md`, `a.md`},
+		{`<a href="/docs/a.md">`, `<a href="/docs/a.html">`},
+		{`<a href="//example.com/a.md">`, `<a href="//example.com/a.md">`},
+		{`<pre><code><a href="a.md"></code></pre><a href="b.md">`, `<pre><code><a href="a.md"></code></pre><a href="b.html">`},
+		{"<p><code><a href=\"a.md\"></code> <a href=\"c.md\"></p>\n<pre class=\"x\">\n<a href=\"d.md\">\n</pre>", "<p><code><a href=\"a.md\"></code> <a href=\"c.html\"></p>\n<pre class=\"x\">\n<a href=\"d.md\">\n</pre>"},
+	}
+
+	for _, tt := range tests {
+		actual := string(RewriteLinks([]byte(tt.input)))
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
@@ -3,31 +3,160 @@ package converter
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"godown/ast"
 	"godown/evaluator"
 	"godown/lexer"
 	"godown/parser"
+	"html"
 	"io"
 )
 
-func Convert(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+var (
+	// 入力がOptions.MaxInputSizeを超えた
+	ErrInputTooLarge = errors.New("converter: input too large")
+	// 行がOptions.MaxLineLengthを超えた
+	ErrLineTooLong = errors.New("converter: line too long")
+	// サイトの入力にSiteStylesheetと同じ名前のファイルがある
+	ErrStylesheetConflict = errors.New("converter: file name is used by the generated stylesheet")
+)
+
+// ASTを任意の形式で出力するレンダラー
+type Renderer interface {
+	Render(w io.Writer, doc *ast.Document) error
+}
+
+// 関数をRendererとして扱うためのアダプタ
+type RendererFunc func(w io.Writer, doc *ast.Document) error
+
+func (f RendererFunc) Render(w io.Writer, doc *ast.Document) error {
+	return f(w, doc)
+}
+
+// 変換の設定
+type Options struct {
+	Extensions parser.Extensions // パーサで有効にする拡張機能
+	Renderer   Renderer          // 出力に使うレンダラー(nilの場合はHTML)
+	Theme      string            // HTML出力に埋め込むCSSテーマのファイルパス
+	Safe       bool              // HTML出力で入力中のHTMLをエスケープする
+	// HTML出力で.mdファイルへの相対リンクを.htmlへのリンクに書き換える
+	RewriteLinks bool
+	// HTML出力のコードブロックにコピーボタンを付ける
+	CopyButton bool
+
+	MaxInputSize  int64 // 入力の最大バイト数(0の場合は無制限)
+	MaxLineLength int   // 1行の最大バイト数(0の場合は無制限)
+}
+
+// 既定の設定
+func DefaultOptions() Options {
+	return Options{Extensions: parser.CommonExtensions}
+}
+
+// inから読み込んだMarkdown文書を変換してoutに書き出す
+func Convert(ctx context.Context, in io.Reader, out io.Writer, opts Options) error {
+	input, err := read(ctx, in, opts)
+	if err != nil {
+		return err
+	}
+
+	l := lexer.New(input)
+	p := parser.NewWithExtensions(l, opts.Extensions)
+
+	document := p.ParseDocument()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	renderer := opts.Renderer
+	if renderer == nil {
+		renderer = RendererFunc(func(w io.Writer, doc *ast.Document) error {
+			return renderHTML(w, doc, opts)
+		})
+	}
+
+	return renderer.Render(out, document)
+}
+
+// 入力を読み込み、サイズの制限を検査する
+func read(ctx context.Context, in io.Reader, opts Options) (string, error) {
+	if opts.MaxInputSize > 0 {
+		// 制限を超えたことを検出するため1バイト余分に読む
+		in = io.LimitReader(in, opts.MaxInputSize+1)
+	}
+	r := bufio.NewReader(in)
 
 	var buf bytes.Buffer
 	for {
-		scanned := scanner.Scan()
-		if !scanned {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			if opts.MaxLineLength > 0 && len(bytes.TrimRight([]byte(line), "\r\n")) > opts.MaxLineLength {
+				return "", fmt.Errorf("%w: line %d", ErrLineTooLong, bytes.Count(buf.Bytes(), []byte("\n"))+1)
+			}
+			buf.WriteString(line)
+			if opts.MaxInputSize > 0 && int64(buf.Len()) > opts.MaxInputSize {
+				return "", ErrInputTooLarge
+			}
+		}
+
+		if err == io.EOF {
 			break
 		}
-		buf.WriteString(scanner.Text())
+		if err != nil {
+			return "", fmt.Errorf("converter: read input: %w", err)
+		}
+	}
+
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
 		buf.WriteString("\n")
 	}
 
-	input := buf.String()
-	l := lexer.New(input)
-	p := parser.New(l)
+	return buf.String(), nil
+}
 
-	document := p.ParseDocument()
+// HTML文書として出力する
+func renderHTML(w io.Writer, doc *ast.Document, opts Options) error {
+	if opts.Safe {
+		escapeText(doc)
+	}
 
-	evaluated := evaluator.Eval(document)
-	io.WriteString(out, evaluated.Render())
+	evaluated := evaluator.Eval(doc)
+	evaluated.Theme = opts.Theme
+	evaluated.CopyButton = opts.CopyButton
+
+	rendered, err := evaluated.Render()
+	if err != nil {
+		return fmt.Errorf("converter: load theme: %w", err)
+	}
+
+	if opts.RewriteLinks {
+		rendered = string(RewriteLinks([]byte(rendered)))
+	}
+
+	if _, err := io.WriteString(w, rendered); err != nil {
+		return fmt.Errorf("converter: write output: %w", err)
+	}
+
+	return nil
+}
+
+// テキストに含まれるHTMLをエスケープする
+// コードブロックは出力時に必ずエスケープするため除く
+func escapeText(doc *ast.Document) {
+	ast.Inspect(doc, func(node ast.Node) bool {
+		if _, ok := node.(*ast.CodeBlock); ok {
+			return false
+		}
+		if text, ok := node.(*ast.Text); ok {
+			text.Content = html.EscapeString(text.Content)
+		}
+		return true
+	})
 }
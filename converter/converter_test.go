@@ -0,0 +1,206 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"godown/ast"
+	"io"
+	"strings"
+	"testing"
+)
+
+const testTheme = "../res/godown.css"
+
+// 文書をそのまま文字列化するレンダラー
+var stringRenderer = RendererFunc(func(w io.Writer, doc *ast.Document) error {
+	_, err := io.WriteString(w, doc.String())
+	return err
+})
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) { return 0, errors.New("read failed") }
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestConvert(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Theme = testTheme
+
+	var out bytes.Buffer
+	err := Convert(context.Background(), strings.NewReader("# heading\r\n"), &out, opts)
+	if err != nil {
+		t.Fatalf("Convert returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(out.String(), "<!DOCTYPE html>") {
+		t.Errorf("output is not a HTML document. got=%q", out.String())
+	}
+	if !strings.Contains(out.String(), "<h1>heading</h1>\n") {
+		t.Errorf("output does not contain heading. got=%q", out.String())
+	}
+}
+
+func TestConvertOptions(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			"~~a~~",
+			Options{Extensions: DefaultOptions().Extensions, Renderer: stringRenderer},
+			"<p><s>a</s></p>\n",
+		},
+		{
+			"~~a~~",
+			Options{Renderer: stringRenderer},
+			"<p>~~a~~</p>\n",
+		},
+		{
+			"a " + strings.Repeat("b", 70*1024),
+			Options{Renderer: stringRenderer},
+			"<p>a " + strings.Repeat("b", 70*1024) + "</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := Convert(context.Background(), strings.NewReader(tt.input), &out, tt.opts)
+		if err != nil {
+			t.Errorf("input=%q returned error: %v", tt.input, err)
+			continue
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestConvertSafe(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Theme = testTheme
+	opts.Safe = true
+
+	var out bytes.Buffer
+	err := Convert(context.Background(), strings.NewReader("<script>alert(1)</script>"), &out, opts)
+	if err != nil {
+		t.Fatalf("Convert returned error: %v", err)
+	}
+
+	if strings.Contains(out.String(), "<script>") {
+		t.Errorf("raw HTML was not escaped. got=%q", out.String())
+	}
+	if !strings.Contains(out.String(), "&lt;script&gt;") {
+		t.Errorf("escaped HTML not found. got=%q", out.String())
+	}
+}
+
+// コードブロックはハイライトするかどうかによらず一度だけエスケープする
+func TestConvertSafeHighlight(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Theme = testTheme
+	opts.Safe = true
+
+	for _, input := range []string{
+		"```go\nx := a < b\n```\n",
+		"```unknown\nx := a < b\n```\n",
+		"    x := a < b\n",
+	} {
+		var out bytes.Buffer
+		err := Convert(context.Background(), strings.NewReader(input), &out, opts)
+		if err != nil {
+			t.Fatalf("Convert returned error: %v", err)
+		}
+
+		if !strings.Contains(out.String(), "x := a &lt; b") {
+			t.Errorf("input=%q: code was not escaped exactly once. got=%q", input, out.String())
+		}
+	}
+}
+
+func TestConvertCopyButton(t *testing.T) {
+	for _, copyButton := range []bool{false, true} {
+		opts := DefaultOptions()
+		opts.Theme = testTheme
+		opts.CopyButton = copyButton
+
+		var out bytes.Buffer
+		err := Convert(context.Background(), strings.NewReader("```go\nx\n```\n"), &out, opts)
+		if err != nil {
+			t.Fatalf("Convert returned error: %v", err)
+		}
+
+		if strings.Contains(out.String(), "<script>") != copyButton {
+			t.Errorf("CopyButton=%v wrong script. got=%q", copyButton, out.String())
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		in       io.Reader
+		out      io.Writer
+		opts     Options
+		expected error
+	}{
+		{
+			"input too large",
+			context.Background(),
+			strings.NewReader("# heading\n\ntext\n"),
+			io.Discard,
+			Options{Renderer: stringRenderer, MaxInputSize: 10},
+			ErrInputTooLarge,
+		},
+		{
+			"line too long",
+			context.Background(),
+			strings.NewReader("# heading\n" + strings.Repeat("a", 100) + "\n"),
+			io.Discard,
+			Options{Renderer: stringRenderer, MaxLineLength: 50},
+			ErrLineTooLong,
+		},
+		{
+			"canceled",
+			canceled,
+			strings.NewReader("# heading\n"),
+			io.Discard,
+			Options{Renderer: stringRenderer},
+			context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		err := Convert(tt.ctx, tt.in, tt.out, tt.opts)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected error %v, got=%v", tt.name, tt.expected, err)
+		}
+	}
+
+	ioTests := []struct {
+		name string
+		in   io.Reader
+		out  io.Writer
+		opts Options
+	}{
+		{"read error", errReader{}, io.Discard, Options{Renderer: stringRenderer}},
+		{"write error", strings.NewReader("# heading\n"), errWriter{}, Options{Theme: testTheme}},
+		{"missing theme", strings.NewReader("# heading\n"), io.Discard, Options{Theme: "no-such-theme.css"}},
+	}
+
+	for _, tt := range ioTests {
+		err := Convert(context.Background(), tt.in, tt.out, tt.opts)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"godown/ast"
+	"godown/decorator"
+	"godown/evaluator"
+	"godown/lexer"
+	"godown/parser"
+	"html"
+	"html/template"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// サイト全体で共有するスタイルシートのファイル名
+const SiteStylesheet = "godown.css"
+
+// 既定のレイアウト
+const defaultLayout = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Page.Title}}{{if .Site.Title}} - {{.Site.Title}}{{end}}</title>
+<link rel="stylesheet" href="{{.Root}}` + SiteStylesheet + `">
+<style>
+.site { display: flex; max-width: 1100px; margin: 0 auto; }
+.site-nav { flex: 0 0 220px; padding: 16px; border-right: 1px solid #eaecef; }
+.site-nav ul { list-style: none; padding-left: 1em; }
+.site-nav .active > a { font-weight: 600; }
+.site-main { flex: 1; min-width: 0; padding: 16px 32px; }
+.site-toc { font-size: 90%; }
+.site-pager { display: flex; justify-content: space-between; margin-top: 32px; }
+</style>
+</head>
+<body for="html-export" class="body site">
+<nav class="site-nav">
+<a href="{{.Root}}index.html">{{or .Site.Title "Home"}}</a>
+{{.Nav}}</nav>
+<main class="site-main">
+{{if .TOC}}<nav class="site-toc">
+<ul>
+{{range .TOC}}<li style="margin-left: {{.Indent}}em"><a href="#{{.ID}}">{{.Title}}</a></li>
+{{end}}</ul>
+</nav>
+{{end}}{{.Content}}
+<nav class="site-pager">
+<span>{{with .Prev}}<a href="{{$.Root}}{{.Path}}">&larr; {{.Title}}</a>{{end}}</span>
+<span>{{with .Next}}<a href="{{$.Root}}{{.Path}}">{{.Title}} &rarr;</a>{{end}}</span>
+</nav>
+</main>
+</body>
+</html>
+`
+
+// サイト生成の設定
+type SiteOptions struct {
+	Options
+
+	Title  string // サイトのタイトル
+	Layout string // ページのレイアウトに使うhtml/templateのファイル(空の場合は既定のレイアウト)
+}
+
+// サイトの1ページ
+type Page struct {
+	Source string // 入力ディレクトリからの相対パス
+	Path   string // サイトのルートからの相対URL
+	Title  string // front matterのtitle、最初の見出し、ファイル名の順に決める
+	Weight int    // front matterのweight(小さいほど前に並ぶ。0は未指定)
+
+	document *ast.Document
+}
+
+// ページ内の目次の項目
+type TOCEntry struct {
+	Level  int
+	Indent int // 最も浅い見出しからの深さ
+	ID     string
+	Title  string
+}
+
+// サイドバーのナビゲーションの項目
+type NavItem struct {
+	Title    string
+	Page     *Page // ディレクトリの場合はindex.mdのページ(なければnil)
+	Children []*NavItem
+
+	weight int
+}
+
+// レイアウトに渡すデータ
+type layoutData struct {
+	Site    SiteOptions
+	Page    *Page
+	Root    string // ページからサイトのルートへの相対パス
+	Nav     template.HTML
+	TOC     []TOCEntry
+	Content template.HTML
+	Prev    *Page
+	Next    *Page
+}
+
+// inDir以下のMarkdownからナビゲーション付きのサイトをoutDirに生成する
+// Markdown以外のファイルはそのままコピーする
+// ただし最上位のSiteStylesheetと同じ名前のファイルはErrStylesheetConflictとして報告する
+func BuildSite(ctx context.Context, inDir, outDir string, opts SiteOptions) (*BatchReport, error) {
+	layout, err := loadLayout(opts.Layout)
+	if err != nil {
+		return nil, err
+	}
+
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BatchReport{}
+	root := &NavItem{}
+	dirs := map[string]*NavItem{".": root}
+
+	err = filepath.WalkDir(inDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(inDir, name)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if abs, err := filepath.Abs(name); err == nil && abs == absOut {
+				return filepath.SkipDir
+			}
+			if rel != "." {
+				item := &NavItem{Title: d.Name()}
+				parent := dirs[filepath.Dir(rel)]
+				parent.Children = append(parent.Children, item)
+				dirs[rel] = item
+			}
+			return nil
+		}
+
+		if !IsMarkdown(rel) {
+			// 生成するスタイルシートで上書きされるため、コピーせずに報告する
+			if filepath.ToSlash(rel) == SiteStylesheet {
+				report.Errors = append(report.Errors, &FileError{Path: rel, Err: ErrStylesheetConflict})
+				return nil
+			}
+			if err := CopyFile(name, filepath.Join(outDir, rel)); err != nil {
+				report.Errors = append(report.Errors, &FileError{Path: rel, Err: err})
+			} else {
+				report.Copied = append(report.Copied, rel)
+			}
+			return nil
+		}
+
+		page, err := loadPage(ctx, name, rel, opts.Options)
+		if err != nil {
+			report.Errors = append(report.Errors, &FileError{Path: rel, Err: err})
+			return nil
+		}
+
+		dir := dirs[filepath.Dir(rel)]
+		if isIndex(rel) {
+			dir.Page = page
+			if rel != "index.md" {
+				dir.Title = page.Title
+				dir.weight = page.Weight
+			}
+			return nil
+		}
+		dir.Children = append(dir.Children, &NavItem{Title: page.Title, Page: page, weight: page.Weight})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortNav(root)
+
+	if root.Page == nil {
+		root.Page = indexPage(opts.Title, root)
+	}
+
+	// 前後のページへのリンクはナビゲーションの順に張る
+	pages := []*Page{root.Page}
+	flattenNav(root, &pages)
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := writeStylesheet(filepath.Join(outDir, SiteStylesheet), opts.Theme); err != nil {
+		return nil, err
+	}
+
+	for i, page := range pages {
+		data := layoutData{
+			Site: opts,
+			Page: page,
+			Root: strings.Repeat("../", strings.Count(page.Path, "/")),
+			TOC:  tableOfContents(page.document),
+		}
+		data.Nav = navHTML(root, page, data.Root)
+		if i > 0 {
+			data.Prev = pages[i-1]
+		}
+		if i < len(pages)-1 {
+			data.Next = pages[i+1]
+		}
+
+		if err := writePage(filepath.Join(outDir, filepath.FromSlash(page.Path)), layout, data, opts.Options); err != nil {
+			report.Errors = append(report.Errors, &FileError{Path: page.Source, Err: err})
+		} else if page.Source != "" {
+			report.Converted = append(report.Converted, page.Source)
+		}
+	}
+
+	sort.Strings(report.Converted)
+	sort.Strings(report.Copied)
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Path < report.Errors[j].Path
+	})
+
+	return report, nil
+}
+
+func loadLayout(name string) (*template.Template, error) {
+	if name == "" {
+		return template.New("layout").Parse(defaultLayout)
+	}
+
+	return template.ParseFiles(name)
+}
+
+// Markdownファイルを読み込んでページを作る
+func loadPage(ctx context.Context, name, rel string, opts Options) (*Page, error) {
+	in, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	input, err := read(ctx, in, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l := lexer.New(input)
+	p := parser.NewWithExtensions(l, opts.Extensions|parser.FrontMatter|parser.HeadingIDs)
+	document := p.ParseDocument()
+
+	page := &Page{
+		Source:   rel,
+		Path:     filepath.ToSlash(HTMLPath(rel)),
+		Title:    document.FrontMatter["title"],
+		document: document,
+	}
+
+	if page.Title == "" {
+		for _, block := range document.Blocks {
+			if heading, ok := block.(*ast.Heading); ok {
+				page.Title = ast.PlainText(heading)
+				break
+			}
+		}
+	}
+	if page.Title == "" {
+		page.Title = strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+	}
+
+	if weight, ok := document.FrontMatter["weight"]; ok {
+		page.Weight, err = strconv.Atoi(weight)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight %q", weight)
+		}
+	}
+
+	return page, nil
+}
+
+func isIndex(rel string) bool {
+	return strings.EqualFold(filepath.Base(rel), "index.md")
+}
+
+// weight、タイトルの順にナビゲーションを並べる
+// weightのない項目はweightのある項目の後に並べる
+func sortNav(item *NavItem) {
+	weight := func(n *NavItem) int {
+		if n.weight == 0 {
+			return math.MaxInt
+		}
+		return n.weight
+	}
+
+	sort.SliceStable(item.Children, func(i, j int) bool {
+		a, b := item.Children[i], item.Children[j]
+		if weight(a) != weight(b) {
+			return weight(a) < weight(b)
+		}
+		return a.Title < b.Title
+	})
+
+	for _, child := range item.Children {
+		sortNav(child)
+	}
+}
+
+func flattenNav(item *NavItem, pages *[]*Page) {
+	for _, child := range item.Children {
+		if child.Page != nil {
+			*pages = append(*pages, child.Page)
+		}
+		flattenNav(child, pages)
+	}
+}
+
+// index.mdがない場合に生成するトップページ
+func indexPage(title string, root *NavItem) *Page {
+	if title == "" {
+		title = "Index"
+	}
+
+	document := &ast.Document{}
+	heading := &ast.Heading{Level: 1, ID: ast.Slug(title), Contents: []ast.Inline{&ast.Text{Content: title}}}
+	document.Blocks = append(document.Blocks, heading)
+
+	return &Page{Path: "index.html", Title: title, document: document}
+}
+
+// 見出しから目次を作る
+func tableOfContents(document *ast.Document) []TOCEntry {
+	var toc []TOCEntry
+
+	minLevel := 0
+	for _, block := range document.Blocks {
+		heading, ok := block.(*ast.Heading)
+		if !ok || heading.ID == "" {
+			continue
+		}
+		if minLevel == 0 || heading.Level < minLevel {
+			minLevel = heading.Level
+		}
+		toc = append(toc, TOCEntry{Level: heading.Level, ID: heading.ID, Title: ast.PlainText(heading)})
+	}
+
+	for i := range toc {
+		toc[i].Indent = toc[i].Level - minLevel
+	}
+
+	// 見出しが1つだけなら目次は出さない
+	if len(toc) < 2 {
+		return nil
+	}
+
+	return toc
+}
+
+// サイドバーのナビゲーションをHTMLにする
+func navHTML(item *NavItem, current *Page, root string) template.HTML {
+	var out bytes.Buffer
+	writeNav(&out, item, current, root)
+	return template.HTML(out.String())
+}
+
+func writeNav(out *bytes.Buffer, item *NavItem, current *Page, root string) {
+	if len(item.Children) == 0 {
+		return
+	}
+
+	out.WriteString("<ul>\n")
+	for _, child := range item.Children {
+		if child.Page == current {
+			out.WriteString("<li class=\"active\">")
+		} else {
+			out.WriteString("<li>")
+		}
+
+		title := html.EscapeString(child.Title)
+		if child.Page != nil {
+			out.WriteString("<a href=\"" + html.EscapeString(root+child.Page.Path) + "\">" + title + "</a>")
+		} else {
+			out.WriteString(title)
+		}
+		out.WriteString("\n")
+
+		writeNav(out, child, current, root)
+		out.WriteString("</li>\n")
+	}
+	out.WriteString("</ul>\n")
+}
+
+func writeStylesheet(name, theme string) error {
+	var out bytes.Buffer
+	if err := decorator.Deco(&out, theme); err != nil {
+		return fmt.Errorf("converter: load theme: %w", err)
+	}
+
+	return os.WriteFile(name, out.Bytes(), 0644)
+}
+
+func writePage(name string, layout *template.Template, data layoutData, opts Options) error {
+	if opts.Safe {
+		escapeText(data.Page.document)
+	}
+
+	evaluated := evaluator.Eval(data.Page.document)
+	data.Content = template.HTML(RewriteLinks([]byte(evaluated.Inspect())))
+
+	var out bytes.Buffer
+	if err := layout.Execute(&out, data); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, out.Bytes(), 0644)
+}
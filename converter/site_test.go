@@ -0,0 +1,158 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildSite(t *testing.T) {
+	in := t.TempDir()
+	out := t.TempDir()
+
+	writeFiles(t, in, map[string]string{
+		"intro.md":          "---\ntitle: Introduction\nweight: 1\n---\n# Intro\n## Install\n## Usage\n",
+		"faq.md":            "# FAQ\n",
+		"guide/index.md":    "---\nweight: 2\n---\n# Guide\n",
+		"guide/advanced.md": "---\nweight: 2\n---\n# Advanced\n",
+		"guide/basics.md":   "---\nweight: 1\n---\n# Basics\n<a href=\"advanced.md\">next</a>\n",
+		"guide/image.png":   "png",
+	})
+
+	opts := SiteOptions{Options: DefaultOptions(), Title: "Handbook"}
+	opts.Theme = testTheme
+
+	report, err := BuildSite(context.Background(), in, out, opts)
+	if err != nil {
+		t.Fatalf("BuildSite returned error: %v", err)
+	}
+	if len(report.Errors) != 0 {
+		t.Fatalf("BuildSite reported errors: %v", report.Errors)
+	}
+
+	expected := []string{"faq.md", filepath.Join("guide", "advanced.md"), filepath.Join("guide", "basics.md"),
+		filepath.Join("guide", "index.md"), "intro.md"}
+	if !reflect.DeepEqual(report.Converted, expected) {
+		t.Errorf("converted wrong. expected=%v, got=%v", expected, report.Converted)
+	}
+
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		file     string
+		contains []string
+	}{
+		{
+			"index.html",
+			[]string{"<title>Handbook - Handbook</title>", `<h1 id="handbook">Handbook</h1>`,
+				`<a href="intro.html">Introduction &rarr;</a>`},
+		},
+		{
+			"intro.html",
+			[]string{
+				"<title>Introduction - Handbook</title>",
+				`<link rel="stylesheet" href="godown.css">`,
+				`<li class="active"><a href="intro.html">Introduction</a>`,
+				`<a href="#install">Install</a>`,
+				`<h2 id="usage">Usage</h2>`,
+				`<a href="index.html">&larr; Handbook</a>`,
+				`<a href="guide/index.html">Guide &rarr;</a>`,
+			},
+		},
+		{
+			filepath.Join("guide", "basics.html"),
+			[]string{
+				`<link rel="stylesheet" href="../godown.css">`,
+				`<a href="../guide/index.html">Guide</a>`,
+				`<a href="advanced.html">next</a>`,
+				`<a href="../guide/index.html">&larr; Guide</a>`,
+				`<a href="../guide/advanced.html">Advanced &rarr;</a>`,
+			},
+		},
+		{
+			filepath.Join("guide", "advanced.html"),
+			[]string{`<a href="../faq.html">FAQ &rarr;</a>`},
+		},
+	}
+
+	for _, tt := range tests {
+		page := read(tt.file)
+		for _, c := range tt.contains {
+			if !strings.Contains(page, c) {
+				t.Errorf("%s does not contain %q", tt.file, c)
+			}
+		}
+	}
+
+	// ナビゲーションはweight、タイトルの順に並ぶ
+	nav := read("faq.html")
+	order := []string{"Introduction", "Guide", "Basics", "Advanced", "FAQ"}
+	last := -1
+	for _, title := range order {
+		i := strings.Index(nav, ">"+title+"</a>")
+		if i < last {
+			t.Errorf("navigation order wrong at %q", title)
+		}
+		last = i
+	}
+
+	if read(filepath.Join("guide", "image.png")) != "png" {
+		t.Errorf("asset was not copied")
+	}
+	if !strings.Contains(read(SiteStylesheet), ".body") {
+		t.Errorf("stylesheet was not written")
+	}
+}
+
+// 入力のgodown.cssは生成するスタイルシートで上書きせずに報告する
+func TestBuildSiteStylesheetConflict(t *testing.T) {
+	in := t.TempDir()
+	out := t.TempDir()
+
+	writeFiles(t, in, map[string]string{
+		"index.md":       "# Home\n",
+		"b.md":           "---\nweight: x\n---\n# B\n",
+		SiteStylesheet:   "body { color: red; }",
+		"css/godown.css": "nested",
+	})
+
+	opts := SiteOptions{Options: DefaultOptions()}
+	opts.Theme = testTheme
+
+	report, err := BuildSite(context.Background(), in, out, opts)
+	if err != nil {
+		t.Fatalf("BuildSite returned error: %v", err)
+	}
+
+	var paths []string
+	for _, e := range report.Errors {
+		paths = append(paths, e.Path)
+	}
+	if expected := []string{"b.md", SiteStylesheet}; !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("errors wrong. expected=%v, got=%v", expected, report.Errors)
+	}
+	if !errors.Is(report.Errors[1], ErrStylesheetConflict) {
+		t.Errorf("wrong error. got=%v", report.Errors[1])
+	}
+	if expected := []string{filepath.Join("css", "godown.css")}; !reflect.DeepEqual(report.Copied, expected) {
+		t.Errorf("copied wrong. expected=%v, got=%v", expected, report.Copied)
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, SiteStylesheet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "color: red") {
+		t.Errorf("generated stylesheet was overwritten by the input file")
+	}
+}
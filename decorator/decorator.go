@@ -3,21 +3,32 @@ package decorator
 import (
 	"bufio"
 	"bytes"
-	"log"
 	"os"
 )
 
-func Deco(out *bytes.Buffer) {
-	file, err := os.Open("./res/godown.css")
+// 既定のCSSテーマ
+const DefaultTheme = "./res/godown.css"
+
+// CSSテーマを読み込んでoutに書き出す
+// themeが空の場合は既定のテーマを使う
+func Deco(out *bytes.Buffer, theme string) error {
+	if theme == "" {
+		theme = DefaultTheme
+	}
+
+	file, err := os.Open(theme)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
 	for scanner.Scan() {
 		line := scanner.Text()
 		out.WriteString(line)
 		out.WriteString("\n")
 	}
+
+	return scanner.Err()
 }
@@ -0,0 +1,39 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{
+			"a\nb\nc\n",
+			"a\nB\nc\n",
+			"--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\nX\n",
+			"--- old\n+++ new\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+X\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"X\n2\n3\n4\n5\n6\n7\n8\n9\nY\n",
+			"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+Y\n",
+		},
+		{
+			"",
+			"a\n",
+			"--- old\n+++ new\n@@ -1,0 +1,1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Unified("old", "new", tt.a, tt.b)
+		if actual != tt.expected {
+			t.Errorf("a=%q b=%q wrong. expected=%q, got=%q", tt.a, tt.b, tt.expected, actual)
+		}
+	}
+}
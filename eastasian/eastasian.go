@@ -0,0 +1,49 @@
+package eastasian
+
+import "unicode"
+
+// 端末で2桁の幅で表示する文字(East Asian WideとFullwidth)
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1}, // ハングル字母
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1}, // CJK部首、句読点
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1}, // ひらがな、カタカナ、CJK互換文字
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1}, // CJK統合漢字拡張A
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1}, // CJK統合漢字
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1}, // イ文字
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1}, // ハングル字母拡張A
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1}, // ハングル音節
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1}, // CJK互換漢字
+		{Lo: 0xfe10, Hi: 0xfe19, Stride: 1}, // 縦書き用の句読点
+		{Lo: 0xfe30, Hi: 0xfe6f, Stride: 1}, // CJK互換形、小字形
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1}, // 全角英数字と記号
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1}, // 全角記号
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1}, // 絵文字
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1}, // 補助絵文字
+		{Lo: 0x20000, Hi: 0x2fffd, Stride: 1}, // CJK統合漢字拡張B以降
+		{Lo: 0x30000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// 文字を端末に表示する幅
+// 東アジアの全角文字は2、結合文字と書式制御文字は0、それ以外は1とする
+func RuneWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wide, r):
+		return 2
+	}
+	return 1
+}
+
+// 文字列を端末に表示する幅
+func Width(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
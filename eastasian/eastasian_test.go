@@ -0,0 +1,27 @@
+package eastasian
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"日本語", 6},
+		{"aかナ한", 7},
+		{"ＡＢ", 4},
+		{"é", 1},
+		{"é", 1},
+		{"─│", 2},
+		{"🍣", 2},
+	}
+
+	for _, tt := range tests {
+		actual := Width(tt.input)
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%d, got=%d", tt.input, tt.expected, actual)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package evaluator
 
 import (
+	"bytes"
 	"godown/ast"
+	"godown/highlight"
 	"godown/object"
+	"html"
+	"strconv"
+	"strings"
 )
 
 func Eval(node ast.Node) object.Document {
@@ -34,17 +39,76 @@ func evalDocument(document *ast.Document) object.Document {
 		}
 	}
 
+	if footnotes := evalFootnotes(document); footnotes != nil {
+		evaluated.Objects = append(evaluated.Objects, footnotes)
+	}
+
 	return evaluated
 }
 
+// 参照された脚注を番号の順に並べ、参照元に戻るリンクを付ける
+// 参照されていない脚注は出力しない
+func evalFootnotes(document *ast.Document) *object.Footnotes {
+	refs := map[int][]*ast.FootnoteReference{}
+	ast.Inspect(document, func(node ast.Node) bool {
+		if ref, ok := node.(*ast.FootnoteReference); ok {
+			refs[ref.Index] = append(refs[ref.Index], ref)
+		}
+		return true
+	})
+
+	var out bytes.Buffer
+	for _, footnote := range document.Footnotes {
+		if footnote.Index == 0 {
+			continue
+		}
+
+		var backrefs bytes.Buffer
+		for i, ref := range refs[footnote.Index] {
+			if i > 0 {
+				backrefs.WriteString(" ")
+			}
+			backrefs.WriteString("<a href=\"#" + ref.RefID() + "\" class=\"footnote-backref\">↩")
+			if ref.Ref > 1 {
+				backrefs.WriteString("<sup>" + strconv.Itoa(ref.Ref) + "</sup>")
+			}
+			backrefs.WriteString("</a>")
+		}
+
+		out.WriteString("<li id=\"fn-" + strconv.Itoa(footnote.Index) + "\">\n")
+
+		placed := false
+		for i, block := range footnote.Blocks {
+			value := EvalBlock(block).Inspect()
+			// 最後のパラグラフの中に戻るリンクを置く
+			if _, ok := block.(*ast.Paragraph); ok && i == len(footnote.Blocks)-1 {
+				value = strings.TrimSuffix(value, "</p>\n") + " " + backrefs.String() + "</p>\n"
+				placed = true
+			}
+			out.WriteString(value)
+		}
+		if !placed {
+			out.WriteString("<p>" + backrefs.String() + "</p>\n")
+		}
+
+		out.WriteString("</li>\n")
+	}
+
+	if out.Len() == 0 {
+		return nil
+	}
+
+	return &object.Footnotes{Value: "<section class=\"footnotes\">\n<hr/>\n<ol>\n" + out.String() + "</ol>\n</section>\n"}
+}
+
 func EvalBlock(node ast.Node) object.Object {
 	switch node := node.(type) {
 	case *ast.Heading:
 		return &object.Heading{Value: node.String()}
 	case *ast.DiscList:
-		return &object.DiscList{Value: node.String()}
+		return &object.DiscList{Value: evalDiscList(node)}
 	case *ast.CodeBlock:
-		return &object.CodeBlock{Value: node.String()}
+		return &object.CodeBlock{Value: evalCodeBlock(node)}
 	case *ast.Paragraph:
 		return &object.Paragraph{Value: node.String()}
 	case *ast.HorizontalRule:
@@ -53,3 +117,145 @@ func EvalBlock(node ast.Node) object.Object {
 
 	return nil
 }
+
+// リストを出力する
+// 項目の続きのブロックは項目の内容の後に置く
+func evalDiscList(node *ast.DiscList) string {
+	var out bytes.Buffer
+
+	out.WriteString("<p>\n<ul>\n")
+	for i, item := range node.Lists {
+		out.WriteString("<li>")
+		for _, inline := range item {
+			out.WriteString(inline.String())
+		}
+		if blocks := node.ItemBlocks(i); len(blocks) > 0 {
+			out.WriteString("\n")
+			for _, block := range blocks {
+				out.WriteString(EvalBlock(block).Inspect())
+			}
+		}
+		out.WriteString("</li>\n")
+	}
+	out.WriteString("</ul>\n</p>\n")
+
+	return out.String()
+}
+
+// 言語のLexerが登録されている場合はシンタックスハイライトする
+// ハイライトしない場合もコードはHTMLとしてエスケープする
+// 属性linenosで行番号を、hlで強調する行を、startで最初の行番号を、titleでタイトルを指定できる
+func evalCodeBlock(node *ast.CodeBlock) string {
+	var lang string
+	if node.Lang != nil {
+		lang = node.Lang.String()
+	}
+
+	var code bytes.Buffer
+	for _, c := range node.Contents {
+		code.WriteString(c.String())
+	}
+
+	start, err := strconv.Atoi(node.Attributes["start"])
+	if err != nil {
+		start = 1
+	}
+	count := strings.Count(strings.TrimSuffix(code.String(), "\n"), "\n") + 1
+
+	linenos, _ := strconv.ParseBool(node.Attributes["linenos"])
+	hl := lineRanges(node.Attributes["hl"], start, start+count-1)
+
+	if !linenos && len(hl) == 0 {
+		highlighted, ok := highlight.HTML(lang, code.String())
+		if !ok {
+			highlighted = html.EscapeString(code.String())
+		}
+		return pre(node, highlighted)
+	}
+
+	lines, ok := highlight.Lines(lang, code.String())
+	if !ok {
+		lines = strings.Split(strings.TrimSuffix(html.EscapeString(code.String()), "\n"), "\n")
+	}
+
+	var out bytes.Buffer
+	for i, line := range lines {
+		n := start + i
+
+		class := "line"
+		if hl[n] {
+			class += " hl"
+		}
+
+		out.WriteString("<span class=\"" + class + "\">")
+		if linenos {
+			out.WriteString("<span class=\"ln\">" + strconv.Itoa(n) + "</span>")
+		}
+		out.WriteString(line)
+		out.WriteString("</span>\n")
+	}
+
+	return pre(node, out.String())
+}
+
+// コードを<pre>で囲む
+// 属性titleがある場合は<pre>の上に表示する
+func pre(node *ast.CodeBlock, code string) string {
+	var out bytes.Buffer
+
+	var lang string
+	if node.Lang != nil {
+		lang = node.Lang.String()
+	}
+
+	if title, ok := node.Attributes["title"]; ok {
+		out.WriteString("<div class=\"code-title\">" + html.EscapeString(title) + "</div>")
+		out.WriteString("\n")
+	}
+
+	out.WriteString("<pre class=\"language-" + lang + "\">")
+	out.WriteString("\n")
+	out.WriteString("<code>")
+	out.WriteString("\n")
+	out.WriteString(code)
+	out.WriteString("</code>")
+	out.WriteString("\n")
+	out.WriteString("</pre>")
+	out.WriteString("\n")
+
+	return out.String()
+}
+
+// "3-5,8"のような行の範囲の指定を行番号の集合にする
+// 範囲はコードブロックの行番号(minからmaxまで)に切り詰め、解釈できない範囲と空の範囲は無視する
+func lineRanges(s string, min, max int) map[int]bool {
+	lines := map[int]bool{}
+
+	for _, r := range strings.Split(s, ",") {
+		from, to, found := strings.Cut(strings.TrimSpace(r), "-")
+
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		last := first
+		if found {
+			if last, err = strconv.Atoi(to); err != nil {
+				continue
+			}
+		}
+
+		if first < min {
+			first = min
+		}
+		if last > max {
+			last = max
+		}
+
+		for n := first; n <= last; n++ {
+			lines[n] = true
+		}
+	}
+
+	return lines
+}
@@ -50,6 +50,76 @@ func testHeadingObject(t *testing.T, doc object.Document, expected string) bool
 	return true
 }
 
+func TestCodeBlockObject(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"```go\nx := nil\n```\n",
+			"<pre class=\"language-go\">\n<code>\nx := <span class=\"pl-c1\">nil</span>\n</code>\n</pre>\n",
+		},
+		{
+			"```text\nx := nil\n```\n",
+			"<pre class=\"language-text\">\n<code>\nx := nil\n</code>\n</pre>\n",
+		},
+		{
+			"```go {linenos=true hl=11 start=10}\na\nnil\n```\n",
+			"<pre class=\"language-go\">\n<code>\n" +
+				"<span class=\"line\"><span class=\"ln\">10</span>a</span>\n" +
+				"<span class=\"line hl\"><span class=\"ln\">11</span><span class=\"pl-c1\">nil</span></span>\n" +
+				"</code>\n</pre>\n",
+		},
+		{
+			"```go title=\"<main>.go\"\nx\n```\n",
+			"<div class=\"code-title\">&lt;main&gt;.go</div>\n<pre class=\"language-go\">\n<code>\nx\n</code>\n</pre>\n",
+		},
+		{
+			"```text hl=1,3-4\na\nb\nc\nd\n```\n",
+			"<pre class=\"language-text\">\n<code>\n" +
+				"<span class=\"line hl\">a</span>\n" +
+				"<span class=\"line\">b</span>\n" +
+				"<span class=\"line hl\">c</span>\n" +
+				"<span class=\"line hl\">d</span>\n" +
+				"</code>\n</pre>\n",
+		},
+		{
+			"```unknown\n<b>a & b</b>\n```\n",
+			"<pre class=\"language-unknown\">\n<code>\n&lt;b&gt;a &amp; b&lt;/b&gt;\n</code>\n</pre>\n",
+		},
+		{
+			"```unknown linenos=true\n<b>\n```\n",
+			"<pre class=\"language-unknown\">\n<code>\n" +
+				"<span class=\"line\"><span class=\"ln\">1</span>&lt;b&gt;</span>\n" +
+				"</code>\n</pre>\n",
+		},
+		{
+			"    if a < b && c {\n",
+			"<pre class=\"language-\">\n<code>\nif a &lt; b &amp;&amp; c {\n</code>\n</pre>\n",
+		},
+		{
+			"```text {hl=1-20000000,0-0,5-2 start=2}\na\nb\n```\n",
+			"<pre class=\"language-text\">\n<code>\n" +
+				"<span class=\"line hl\">a</span>\n" +
+				"<span class=\"line hl\">b</span>\n" +
+				"</code>\n</pre>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		result, ok := evaluated.Objects[0].(*object.CodeBlock)
+		if !ok {
+			t.Errorf("object is not CodeBlock. got=%T (%+v)", evaluated.Objects[0], evaluated.Objects[0])
+			continue
+		}
+		if result.Value != tt.expected {
+			t.Errorf("object has wrong value. got=%q, want=%q", result.Value, tt.expected)
+		}
+	}
+}
+
 func TestDocument(t *testing.T) {
 	input := `
 # godwon Markdown Parser in Go
@@ -74,20 +144,28 @@ func TestDocument(t *testing.T) {
 - Table
 `
 
-	expected := `<h1><em>text</em>1MIDASHI1</h1>
-<h2>M2</h2>
-<p><em>2text</em><em>text2</em></p>
-<h2>Heading<em>2</em> <em>text</em></h2>
-<p>3text 999 hoge</p>
+	expected := `<h1>godwon Markdown Parser in Go</h1>
+<h2>Markdown Spec</h2>
 <p>
 <ul>
-<li>buy milk</li>
-<li>mail tanaka</li>
-<li>meeting as 15</li>
+<li>Heading</li>
+<li>Emphasis</li>
+<li>**(em)</li>
+<li>****(strong)</li>
+<li>******(em strong)</li>
+<li>Strikethrough</li>
+<li>~~</li>
+<li>List(DISC)</li>
+<li>List(Decimal)</li>
+<li>Quote</li>
+<li>Horizontal Line</li>
+<li>Code Block</li>
+<li>Inline Code</li>
+<li>Link</li>
+<li>Imange</li>
+<li>Table</li>
 </ul>
 </p>
-<h3>h3<strong>!!!</strong></h3>
-<p>This is a text.</p>
 `
 
 	evaluated := testEval(input)
@@ -97,6 +175,43 @@ func TestDocument(t *testing.T) {
 	}
 }
 
+func TestFootnotes(t *testing.T) {
+	input := "a[^1] b[^x]\n\nc[^1]\n\n[^x]: x\n[^1]: one\n\n    two\n[^unused]: unused\n"
+
+	expected := "<section class=\"footnotes\">\n<hr/>\n<ol>\n" +
+		"<li id=\"fn-1\">\n<p>one</p>\n<p>two " +
+		"<a href=\"#fnref-1\" class=\"footnote-backref\">↩</a> " +
+		"<a href=\"#fnref-1-2\" class=\"footnote-backref\">↩<sup>2</sup></a></p>\n</li>\n" +
+		"<li id=\"fn-2\">\n<p>x <a href=\"#fnref-2\" class=\"footnote-backref\">↩</a></p>\n</li>\n" +
+		"</ol>\n</section>\n"
+
+	evaluated := testEval(input)
+
+	result, ok := evaluated.Objects[len(evaluated.Objects)-1].(*object.Footnotes)
+	if !ok {
+		t.Fatalf("last object is not Footnotes. got=%T", evaluated.Objects[len(evaluated.Objects)-1])
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%q, want=%q", result.Value, expected)
+	}
+}
+
+// 項目の続きのコードブロックもエスケープする
+func TestDiscListObject(t *testing.T) {
+	input := "- a\n  - b\n- c\n\n      x < y & z\n"
+
+	expected := "<p>\n<ul>\n<li>a\n" +
+		"<p>\n<ul>\n<li>b</li>\n</ul>\n</p>\n" +
+		"</li>\n<li>c\n" +
+		"<pre class=\"language-\">\n<code>\nx &lt; y &amp; z\n</code>\n</pre>\n" +
+		"</li>\n</ul>\n</p>\n"
+
+	evaluated := testEval(input)
+	if expected != evaluated.Inspect() {
+		t.Errorf("object has wrong value. got=%q, want=%q", evaluated.Inspect(), expected)
+	}
+}
+
 func TestDocument2(t *testing.T) {
 	input := "# *text*1MIDASHI1"
 
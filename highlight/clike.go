@@ -0,0 +1,187 @@
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(rust, "rust", "rs")
+	Register(json, "json")
+}
+
+// C言語に似た構文の言語の字句解析器
+type cLike struct {
+	keywords     map[string]bool
+	constants    map[string]bool
+	lineComment  string // 1行コメントの開始(空の場合はなし)
+	blockComment bool   // /* */のコメント
+	chars        bool   // 'で囲まれた文字
+	macros       bool   // 名前!の形式のマクロ(Rust)
+	keys         bool   // :が続く文字列をキーとする(JSON)
+}
+
+var rust = &cLike{
+	keywords: words("as async await break const continue crate dyn else enum extern fn for if impl in " +
+		"let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+	constants:    words("true false None Some Ok Err"),
+	lineComment:  "//",
+	blockComment: true,
+	chars:        true,
+	macros:       true,
+}
+
+var json = &cLike{
+	constants: words("true false null"),
+	keys:      true,
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+func (c *cLike) Tokenize(code string) []Token {
+	var tokens []Token
+
+	emit := func(kind Kind, text string) {
+		// 同じ種類の字句は連結する
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind && kind == Plain {
+			tokens[n-1].Text += text
+			return
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: text})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		r, size := utf8.DecodeRuneInString(rest)
+
+		switch {
+		case c.lineComment != "" && strings.HasPrefix(rest, c.lineComment):
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			emit(Comment, rest[:n])
+			i += n
+		case c.blockComment && strings.HasPrefix(rest, "/*"):
+			n := strings.Index(rest[2:], "*/")
+			if n < 0 {
+				n = len(rest)
+			} else {
+				n += 4
+			}
+			emit(Comment, rest[:n])
+			i += n
+		case r == '"':
+			n := quoted(rest, '"')
+			kind := String
+			if c.keys && strings.HasPrefix(strings.TrimLeft(rest[n:], " \t"), ":") {
+				kind = Key
+			}
+			emit(kind, rest[:n])
+			i += n
+		case r == '\'' && c.chars:
+			// 'aのようなライフタイムは文字として扱わない
+			n := quoted(rest, '\'')
+			body := rest[1:n]
+			if !strings.HasSuffix(body, "'") || utf8.RuneCountInString(body) != 2 && !strings.HasPrefix(body, `\`) {
+				emit(Plain, rest[:size])
+				i += size
+				continue
+			}
+			emit(String, rest[:n])
+			i += n
+		case unicode.IsDigit(r) || r == '-' && c.keys && len(rest) > 1 && unicode.IsDigit(rune(rest[1])):
+			n := number(rest)
+			emit(Number, rest[:n])
+			i += n
+		case r == '_' || unicode.IsLetter(r):
+			n := strings.IndexFunc(rest[size:], func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+			})
+			if n < 0 {
+				n = len(rest)
+			} else {
+				n += size
+			}
+			word := rest[:n]
+			next := strings.TrimLeft(rest[n:], " \t")
+
+			switch {
+			case c.keywords[word]:
+				emit(Keyword, word)
+			case c.constants[word]:
+				emit(Constant, word)
+			case c.macros && strings.HasPrefix(rest[n:], "!") && !strings.HasPrefix(rest[n:], "!="):
+				emit(Function, rest[:n+1])
+				n++
+			case strings.HasPrefix(next, "("):
+				emit(Function, word)
+			default:
+				emit(Plain, word)
+			}
+			i += n
+		default:
+			emit(Plain, rest[:size])
+			i += size
+		}
+	}
+
+	return tokens
+}
+
+// 引用符で囲まれた部分の長さを返す
+// \によるエスケープを考慮し、閉じる引用符がない場合は行末まで
+func quoted(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// 数値の長さを返す
+// 1.5e-3や0xffのような表記と、_による区切りを含む
+func number(s string) int {
+	i := 0
+	if s[0] == '-' {
+		i++
+	}
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_':
+		case c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+		case (c == '+' || c == '-') && (s[i-1] == 'e' || s[i-1] == 'E'):
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// 識別子かどうか
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package highlight
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+func init() {
+	Register(LexerFunc(tokenizeGo), "go", "golang")
+}
+
+// 事前宣言された定数
+var goConstants = map[string]bool{"true": true, "false": true, "nil": true, "iota": true}
+
+// go/scannerでGoのソースコードを字句に分割する
+func tokenizeGo(code string) []Token {
+	src := []byte(code)
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var tokens []Token
+	offset := 0
+	prev := -1 // 直前の字句(空白を除く)の位置
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// 改行で自動的に挿入されたセミコロンはソースコードにない
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		start := file.Offset(pos)
+		end := start + len(lit)
+		if lit == "" {
+			end = start + len(tok.String())
+		}
+		if end > len(code) {
+			end = len(code)
+		}
+
+		if start > offset {
+			tokens = append(tokens, Token{Kind: Plain, Text: code[offset:start]})
+		}
+
+		kind := Plain
+		switch {
+		case tok == token.COMMENT:
+			kind = Comment
+		case tok.IsKeyword():
+			kind = Keyword
+		case tok == token.STRING || tok == token.CHAR:
+			kind = String
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			kind = Number
+		case tok == token.IDENT && goConstants[lit]:
+			kind = Constant
+		case tok == token.LPAREN && prev >= 0 && tokens[prev].Kind == Plain && isIdent(tokens[prev].Text):
+			// 直前の識別子は関数名
+			tokens[prev].Kind = Function
+		}
+
+		tokens = append(tokens, Token{Kind: kind, Text: code[start:end]})
+		prev = len(tokens) - 1
+		offset = end
+	}
+
+	if offset < len(code) {
+		tokens = append(tokens, Token{Kind: Plain, Text: code[offset:]})
+	}
+
+	return tokens
+}
@@ -0,0 +1,130 @@
+package highlight
+
+import (
+	"html"
+	"strings"
+	"sync"
+)
+
+// 字句の種類
+type Kind int
+
+const (
+	Plain    Kind = iota // 装飾しない
+	Comment              // コメント
+	Keyword              // 予約語
+	String               // 文字列、文字
+	Number               // 数値
+	Constant             // true、nilなどの定数
+	Function             // 関数名、マクロ名
+	Variable             // シェルの変数
+	Key                  // JSONやYAMLのキー
+)
+
+// 字句の種類ごとのCSSクラス(res/godown.cssのpl-*)
+var classes = map[Kind]string{
+	Comment:  "pl-c",
+	Keyword:  "pl-k",
+	String:   "pl-s",
+	Number:   "pl-c1",
+	Constant: "pl-c1",
+	Function: "pl-en",
+	Variable: "pl-smi",
+	Key:      "pl-ent",
+}
+
+// 字句
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// ソースコードを字句に分割する
+// 字句のTextを連結するとソースコードに戻るようにする
+type Lexer interface {
+	Tokenize(code string) []Token
+}
+
+// 関数をLexerとして扱うためのアダプタ
+type LexerFunc func(code string) []Token
+
+func (f LexerFunc) Tokenize(code string) []Token {
+	return f(code)
+}
+
+var (
+	mu     sync.RWMutex
+	lexers = map[string]Lexer{}
+)
+
+// 言語の名前(と別名)にLexerを登録する
+// 名前の大文字と小文字は区別しない
+func Register(l Lexer, names ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, name := range names {
+		lexers[strings.ToLower(name)] = l
+	}
+}
+
+// 言語の名前に登録されたLexerを返す
+// 登録されていない場合はnilを返す
+func Lookup(lang string) Lexer {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return lexers[strings.ToLower(strings.TrimSpace(lang))]
+}
+
+// ソースコードを言語に応じて<span class="pl-*">で装飾したHTMLにする
+// 言語が登録されていない場合はfalseを返す
+func HTML(lang, code string) (string, bool) {
+	l := Lookup(lang)
+	if l == nil {
+		return "", false
+	}
+
+	var out strings.Builder
+	for _, tok := range l.Tokenize(code) {
+		text := html.EscapeString(tok.Text)
+		if class, ok := classes[tok.Kind]; ok && text != "" {
+			out.WriteString(`<span class="` + class + `">` + text + `</span>`)
+		} else {
+			out.WriteString(text)
+		}
+	}
+
+	return out.String(), true
+}
+
+// HTMLと同じく装飾し、行ごとに分けて返す
+// 複数行にわたる字句は行ごとに<span>を閉じるため、各行はそれだけで正しいHTMLになる
+// 末尾の改行の後の空行は含めない
+func Lines(lang, code string) ([]string, bool) {
+	l := Lookup(lang)
+	if l == nil {
+		return nil, false
+	}
+
+	lines := []string{""}
+	for _, tok := range l.Tokenize(code) {
+		for i, part := range strings.Split(tok.Text, "\n") {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+
+			text := html.EscapeString(part)
+			if class, ok := classes[tok.Kind]; ok && text != "" {
+				text = `<span class="` + class + `">` + text + `</span>`
+			}
+			lines[len(lines)-1] += text
+		}
+	}
+
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines, true
+}
@@ -0,0 +1,108 @@
+package highlight
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var samples = map[string]string{
+	"go":   "package main\n\nimport \"fmt\"\n\n// main\nfunc main() {\n\tx := 1.5e-3 + 'a'\n\tfmt.Println(x, nil) /* c */\n}\n",
+	"rust": "fn main<'a>(s: &'a str) -> i32 {\n    // c\n    let c = '\\n';\n    println!(\"{}\", s);\n    1_000 - 2\n}\n",
+	"sh":   "#!/bin/sh\nif [ -n \"$HOME\" ]; then\n  echo ${USER} $1 'a#b' # c\nfi\n",
+	"json": "{\"a\": [1, -2.5e3, true, null], \"b\": \"x\\\"y\"}\n",
+	"yaml": "# c\n---\nkey: value # note\n- name: \"x\"\n  n: 10\n  ok: true\nlist:\n  - 1\n  - b\n",
+}
+
+// 字句を連結すると元のソースコードに戻る
+func TestTokenizeLossless(t *testing.T) {
+	for lang, code := range samples {
+		var out strings.Builder
+		for _, tok := range Lookup(lang).Tokenize(code) {
+			out.WriteString(tok.Text)
+		}
+		if out.String() != code {
+			t.Errorf("lang=%s tokens do not reproduce the source. got=%q", lang, out.String())
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		lang     string
+		code     string
+		expected string
+	}{
+		{
+			"go",
+			"func f() { return \"<a>\" } // c",
+			`<span class="pl-k">func</span> <span class="pl-en">f</span>() { <span class="pl-k">return</span> <span class="pl-s">&#34;&lt;a&gt;&#34;</span> } <span class="pl-c">// c</span>`,
+		},
+		{
+			"Go",
+			"x := nil + 10",
+			`x := <span class="pl-c1">nil</span> + <span class="pl-c1">10</span>`,
+		},
+		{
+			"rust",
+			"let s: &'a str = 'b'; vec![1]",
+			`<span class="pl-k">let</span> s: &amp;&#39;a str = <span class="pl-s">&#39;b&#39;</span>; <span class="pl-en">vec!</span>[<span class="pl-c1">1</span>]`,
+		},
+		{
+			"bash",
+			"for f in $@; do echo \"$f\" a#b; done # c",
+			`<span class="pl-k">for</span> f <span class="pl-k">in</span> <span class="pl-smi">$@</span>; <span class="pl-k">do</span> echo <span class="pl-s">&#34;$f&#34;</span> a#b; <span class="pl-k">done</span> <span class="pl-c"># c</span>`,
+		},
+		{
+			"json",
+			`{"a": -1, "b": "c"}`,
+			`{<span class="pl-ent">&#34;a&#34;</span>: <span class="pl-c1">-1</span>, <span class="pl-ent">&#34;b&#34;</span>: <span class="pl-s">&#34;c&#34;</span>}`,
+		},
+		{
+			"yml",
+			"a: 'x' # c\n- b: no\n",
+			"<span class=\"pl-ent\">a</span>: <span class=\"pl-s\">&#39;x&#39;</span> <span class=\"pl-c\"># c</span>\n- <span class=\"pl-ent\">b</span>: <span class=\"pl-c1\">no</span>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual, ok := HTML(tt.lang, tt.code)
+		if !ok {
+			t.Errorf("lang=%s not registered", tt.lang)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("lang=%s code=%q wrong.\nexpected=%s\ngot=     %s", tt.lang, tt.code, tt.expected, actual)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	code := "/* a\nb */ x\n\ny\n"
+	expected := []string{`<span class="pl-c">/* a</span>`, `<span class="pl-c">b */</span> x`, "", "y"}
+
+	actual, ok := Lines("go", code)
+	if !ok || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("wrong. expected=%q, got=%q", expected, actual)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if _, ok := HTML("upper", "a"); ok {
+		t.Fatal("unregistered language was highlighted")
+	}
+
+	Register(LexerFunc(func(code string) []Token {
+		return []Token{{Kind: Keyword, Text: strings.ToUpper(code)}}
+	}), "upper")
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(lexers, "upper")
+	})
+
+	actual, ok := HTML("UPPER", "a")
+	if !ok || actual != `<span class="pl-k">A</span>` {
+		t.Errorf("wrong. got=%q, %v", actual, ok)
+	}
+}
@@ -0,0 +1,113 @@
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(LexerFunc(tokenizeShell), "sh", "shell", "bash", "zsh", "console")
+}
+
+var shellKeywords = words("if then else elif fi for while until do done case esac in function select time " +
+	"return export local readonly declare set unset break continue exit source")
+
+// シェルスクリプトを字句に分割する
+func tokenizeShell(code string) []Token {
+	var tokens []Token
+
+	emit := func(kind Kind, text string) {
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind && kind == Plain {
+			tokens[n-1].Text += text
+			return
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: text})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		r, size := utf8.DecodeRuneInString(rest)
+		before, _ := utf8.DecodeLastRuneInString(code[:i])
+		wordStart := i == 0 || unicode.IsSpace(before) || strings.ContainsRune(";&|(", before)
+
+		switch {
+		case r == '#' && wordStart:
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			emit(Comment, rest[:n])
+			i += n
+		case r == '\'':
+			n := strings.IndexByte(rest[1:], '\'')
+			if n < 0 {
+				n = len(rest)
+			} else {
+				n += 2
+			}
+			emit(String, rest[:n])
+			i += n
+		case r == '"':
+			n := len(rest)
+			for j := 1; j < len(rest); j++ {
+				if rest[j] == '\\' {
+					j++
+				} else if rest[j] == '"' {
+					n = j + 1
+					break
+				}
+			}
+			emit(String, rest[:n])
+			i += n
+		case r == '$' && len(rest) > 1:
+			n := variable(rest)
+			if n == 0 {
+				emit(Plain, "$")
+				i++
+				continue
+			}
+			emit(Variable, rest[:n])
+			i += n
+		case (unicode.IsLetter(r) || r == '_') && wordStart:
+			n := strings.IndexFunc(rest, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-'
+			})
+			if n < 0 {
+				n = len(rest)
+			}
+			if shellKeywords[rest[:n]] {
+				emit(Keyword, rest[:n])
+			} else {
+				emit(Plain, rest[:n])
+			}
+			i += n
+		default:
+			emit(Plain, rest[:size])
+			i += size
+		}
+	}
+
+	return tokens
+}
+
+// $から始まる変数の参照の長さを返す
+// $NAME、${...}、$1、$?などの特殊変数に対応し、変数でない場合は0を返す
+func variable(s string) int {
+	switch c := s[1]; {
+	case c == '{':
+		if n := strings.IndexByte(s, '}'); n > 0 {
+			return n + 1
+		}
+		return 0
+	case c >= '0' && c <= '9' || strings.IndexByte("?@*#$!-", c) >= 0:
+		return 2
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		n := 2
+		for n < len(s) && (s[n] == '_' || s[n] >= 'a' && s[n] <= 'z' || s[n] >= 'A' && s[n] <= 'Z' || s[n] >= '0' && s[n] <= '9') {
+			n++
+		}
+		return n
+	}
+	return 0
+}
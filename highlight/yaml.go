@@ -0,0 +1,111 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(LexerFunc(tokenizeYAML), "yaml", "yml")
+}
+
+var (
+	// インデント、シーケンスの記号、キー、:
+	yamlKey = regexp.MustCompile(`^(\s*(?:-\s+)*)("[^"]*"|'[^']*'|[^\s#'"\-][^:#]*?|-[^\s:#][^:#]*?)(\s*:)(\s|$)`)
+	// インデントとシーケンスの記号
+	yamlItem   = regexp.MustCompile(`^\s*(?:-(?:\s+|$))*`)
+	yamlNumber = regexp.MustCompile(`^[-+]?(?:\d[\d_]*(?:\.\d*)?(?:[eE][-+]?\d+)?|0x[\da-fA-F]+|\.inf|\.nan)$`)
+)
+
+var yamlConstants = words("true false True False TRUE FALSE null Null NULL ~ yes no on off")
+
+// YAMLを行ごとに字句に分割する
+func tokenizeYAML(code string) []Token {
+	var tokens []Token
+
+	emit := func(kind Kind, text string) {
+		if text == "" {
+			return
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind && kind == Plain {
+			tokens[n-1].Text += text
+			return
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: text})
+	}
+
+	for _, line := range strings.SplitAfter(code, "\n") {
+		body := strings.TrimSuffix(line, "\n")
+		newline := line[len(body):]
+		trimmed := strings.TrimSpace(body)
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			indent := body[:len(body)-len(strings.TrimLeft(body, " \t"))]
+			emit(Plain, indent)
+			emit(Comment, body[len(indent):])
+		case trimmed == "---" || trimmed == "...":
+			emit(Keyword, body)
+		default:
+			rest := body
+			if m := yamlKey.FindStringSubmatch(body); m != nil {
+				emit(Plain, m[1])
+				emit(Key, m[2])
+				emit(Plain, m[3])
+				rest = body[len(m[1])+len(m[2])+len(m[3]):]
+			} else {
+				prefix := yamlItem.FindString(body)
+				emit(Plain, prefix)
+				rest = body[len(prefix):]
+			}
+			yamlValue(rest, emit)
+		}
+
+		emit(Plain, newline)
+	}
+
+	return tokens
+}
+
+// キーの後の値と行末のコメントを分割する
+func yamlValue(s string, emit func(Kind, string)) {
+	value, comment := s, ""
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '#' && i > 0 && s[i-1] == ' ':
+			value, comment = s[:i-1], s[i-1:]
+		}
+		if comment != "" {
+			break
+		}
+	}
+
+	trimmed := strings.TrimSpace(value)
+	start := strings.Index(value, trimmed)
+	emit(Plain, value[:start])
+
+	kind := Plain
+	switch {
+	case trimmed == "":
+	case trimmed[0] == '"' || trimmed[0] == '\'':
+		kind = String
+	case yamlNumber.MatchString(trimmed):
+		kind = Number
+	case yamlConstants[trimmed]:
+		kind = Constant
+	}
+	emit(kind, trimmed)
+	emit(Plain, value[start+len(trimmed):])
+
+	if comment != "" {
+		emit(Plain, " ")
+		emit(Comment, comment[1:])
+	}
+}
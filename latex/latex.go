@@ -0,0 +1,325 @@
+package latex
+
+import (
+	"bytes"
+	"godown/ast"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 見出しのレベルごとのコマンド(h5以降は最後のコマンド)
+var sections = []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+
+// ASTをLaTeXとして出力するレンダラー
+//   - 見出しは\sectionから\subparagraph
+//   - 強調は\emphと\textbf、打ち消しは\sout(ulemパッケージ)
+//   - リストはitemize
+//   - 言語かタイトルを指定したコードブロックはlstlisting(listingsパッケージ)、それ以外はverbatim
+//     (コードに\end{lstlisting}か\end{verbatim}がある場合はもう一方の環境か、エスケープした等幅のテキスト)
+//   - 水平線は\hrule
+//   - 脚注の参照は\footnotemark、脚注は参照したブロックの後の\footnotetext
+type Renderer struct {
+	// \documentclassから\end{document}までの完全な文書を出力する
+	// タイトル、著者、日付はフロントマターのtitle、author、dateから出力する
+	Standalone bool
+}
+
+// srcをLaTeXに変換する
+func TeX(src string, r *Renderer) string {
+	l := lexer.New(src)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions|parser.FrontMatter)
+	document := p.ParseDocument()
+
+	var out bytes.Buffer
+	r.Render(&out, document)
+
+	return out.String()
+}
+
+func (r *Renderer) Render(w io.Writer, doc *ast.Document) error {
+	var out bytes.Buffer
+
+	if r.Standalone {
+		writePreamble(&out, doc.FrontMatter)
+	}
+
+	footnotes := map[int]*ast.Footnote{}
+	for _, footnote := range doc.Footnotes {
+		if footnote.Index > 0 {
+			footnotes[footnote.Index] = footnote
+		}
+	}
+
+	for i, block := range doc.Blocks {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		writeBlock(&out, block)
+		writeFootnotes(&out, block, footnotes)
+	}
+
+	if r.Standalone {
+		out.WriteString("\n\\end{document}\n")
+	}
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func writePreamble(out *bytes.Buffer, frontMatter map[string]string) {
+	out.WriteString("\\documentclass{article}\n")
+	out.WriteString("\\usepackage[T1]{fontenc}\n")
+	out.WriteString("\\usepackage[utf8]{inputenc}\n")
+	out.WriteString("\\usepackage[normalem]{ulem}\n")
+	out.WriteString("\\usepackage{listings}\n")
+
+	title, ok := frontMatter["title"]
+	if ok {
+		out.WriteString("\\title{" + Escape(title) + "}\n")
+		if author, ok := frontMatter["author"]; ok {
+			out.WriteString("\\author{" + Escape(author) + "}\n")
+		}
+		if date, ok := frontMatter["date"]; ok {
+			out.WriteString("\\date{" + Escape(date) + "}\n")
+		}
+	}
+
+	out.WriteString("\n\\begin{document}\n")
+	if ok {
+		out.WriteString("\\maketitle\n")
+	}
+	out.WriteString("\n")
+}
+
+func writeBlock(out *bytes.Buffer, block ast.Block) {
+	switch block := block.(type) {
+	case *ast.Heading:
+		section := sections[len(sections)-1]
+		if block.Level <= len(sections) {
+			section = sections[block.Level-1]
+		}
+		// 見出しの中では\\で改行できないため、強制改行は空白にする
+		title := strings.ReplaceAll(Inlines(block.Contents), lineBreak, " ")
+		out.WriteString("\\" + section + "{" + strings.TrimSpace(title) + "}\n")
+	case *ast.DiscList:
+		out.WriteString("\\begin{itemize}\n")
+		for i, item := range block.Lists {
+			out.WriteString("  \\item " + paragraph(item) + "\n")
+
+			// 項目の続きのブロックは\itemの中に置き、入れ子のリスト以外は空行で段落を分ける
+			for _, b := range block.ItemBlocks(i) {
+				if _, ok := b.(*ast.DiscList); !ok {
+					out.WriteString("\n")
+				}
+				writeBlock(out, b)
+			}
+		}
+		out.WriteString("\\end{itemize}\n")
+	case *ast.Paragraph:
+		out.WriteString(paragraph(block.Contents) + "\n")
+	case *ast.CodeBlock:
+		var code strings.Builder
+		for _, inline := range block.Contents {
+			code.WriteString(ast.PlainText(inline))
+		}
+		content := code.String()
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+
+		var lang string
+		if block.Lang != nil {
+			lang = strings.TrimSpace(ast.PlainText(block.Lang))
+		}
+
+		title, ok := block.Attributes["title"]
+		listing := lang != "" || ok
+
+		// 環境の終わりの行がコードに含まれている場合は、その環境を使わない
+		endVerbatim := strings.Contains(content, "\\end{verbatim}")
+		endListing := strings.Contains(content, "\\end{lstlisting}")
+		switch {
+		case listing && !endListing:
+			var options []string
+			if language, known := listingsLanguages[strings.ToLower(lang)]; known {
+				options = append(options, "language="+language)
+			}
+			if ok {
+				options = append(options, "title={"+Escape(title)+"}")
+			}
+			begin := "\\begin{lstlisting}"
+			if len(options) > 0 {
+				begin += "[" + strings.Join(options, ", ") + "]"
+			}
+			out.WriteString(begin + "\n" + content + "\\end{lstlisting}\n")
+		case !endVerbatim:
+			writeCodeTitle(out, title)
+			out.WriteString("\\begin{verbatim}\n" + content + "\\end{verbatim}\n")
+		case !listing && !endListing:
+			out.WriteString("\\begin{lstlisting}\n" + content + "\\end{lstlisting}\n")
+		default:
+			writeCodeTitle(out, title)
+			writeEscapedCode(out, content)
+		}
+	case *ast.HorizontalRule:
+		out.WriteString("\\hrule\n")
+	}
+}
+
+// パラグラフの内容をLaTeXにする
+// 段落の始めの\\はエラーになるため、先頭の強制改行は出力しない
+func paragraph(contents []ast.Inline) string {
+	text := strings.TrimSpace(Inlines(contents))
+	for strings.HasPrefix(text, lineBreak) {
+		text = strings.TrimSpace(strings.TrimPrefix(text, lineBreak))
+	}
+	return text
+}
+
+// lstlistingを使えないコードブロックのタイトルを太字の行として出力する
+func writeCodeTitle(out *bytes.Buffer, title string) {
+	if title != "" {
+		out.WriteString("\\noindent\\textbf{" + Escape(title) + "}\n\n")
+	}
+}
+
+// verbatimとlstlistingのどちらの終わりも含むコードを、エスケープした等幅のテキストとして出力する
+// 字下げを保つため、空白とタブは改行しない空白にする
+func writeEscapedCode(out *bytes.Buffer, content string) {
+	out.WriteString("{\\ttfamily\\noindent\n")
+	for i, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		if i > 0 {
+			out.WriteString("\\\\\n")
+		}
+		line = strings.NewReplacer(" ", "~", "\t", "~~~~").Replace(Escape(line))
+		switch {
+		case line == "":
+			line = "\\mbox{}"
+		case strings.HasPrefix(line, "[") || strings.HasPrefix(line, "*"):
+			// 前の行の\\[や\\*として読まれないようにする
+			line = "{}" + line
+		}
+		out.WriteString(line)
+	}
+	out.WriteString("\\par}\n")
+}
+
+// コードブロックの言語名とlistingsパッケージの言語の対応
+// listingsが読み込めない言語(goなど)を指定すると文書がコンパイルできないため、ここにない言語は指定しない
+var listingsLanguages = map[string]string{
+	"awk":      "Awk",
+	"bash":     "bash",
+	"c":        "C",
+	"c++":      "C++",
+	"cpp":      "C++",
+	"haskell":  "Haskell",
+	"html":     "HTML",
+	"java":     "Java",
+	"latex":    "TeX",
+	"lisp":     "Lisp",
+	"lua":      "Lua",
+	"make":     "make",
+	"makefile": "make",
+	"perl":     "Perl",
+	"php":      "PHP",
+	"py":       "Python",
+	"python":   "Python",
+	"r":        "R",
+	"rb":       "Ruby",
+	"ruby":     "Ruby",
+	"sh":       "sh",
+	"shell":    "sh",
+	"sql":      "SQL",
+	"tex":      "TeX",
+	"xml":      "XML",
+}
+
+// ブロックで初めて参照された脚注を\footnotetextとして出力する
+// 脚注の中から参照された脚注も続けて出力する
+func writeFootnotes(out *bytes.Buffer, node ast.Node, footnotes map[int]*ast.Footnote) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		ref, ok := n.(*ast.FootnoteReference)
+		if !ok || ref.Ref != 1 || footnotes[ref.Index] == nil {
+			return true
+		}
+		footnote := footnotes[ref.Index]
+
+		var body bytes.Buffer
+		for i, block := range footnote.Blocks {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			writeBlock(&body, block)
+		}
+		out.WriteString("\\footnotetext[" + strconv.Itoa(ref.Index) + "]{" + strings.TrimSpace(body.String()) + "}\n")
+
+		writeFootnotes(out, footnote, footnotes)
+		return true
+	})
+}
+
+// インライン要素の列をLaTeXにする
+func Inlines(inlines []ast.Inline) string {
+	var out strings.Builder
+
+	for _, inline := range inlines {
+		out.WriteString(Inline(inline))
+	}
+
+	return out.String()
+}
+
+// インライン要素をLaTeXにする
+func Inline(inline ast.Inline) string {
+	switch inline := inline.(type) {
+	case *ast.Emphasis:
+		contents := Inlines(inline.Contents)
+		switch inline.Level {
+		case 1:
+			return "\\emph{" + contents + "}"
+		case 2:
+			return "\\textbf{" + contents + "}"
+		default:
+			return "\\emph{\\textbf{" + contents + "}}"
+		}
+	case *ast.InlineCode:
+		return "\\texttt{" + Escape(ast.PlainText(inline)) + "}"
+	case *ast.Strikethrough:
+		return "\\sout{" + Inlines(inline.Contents) + "}"
+	case *ast.FootnoteReference:
+		return "\\footnotemark[" + strconv.Itoa(inline.Index) + "]"
+	case *ast.SoftBreak:
+		return "\n"
+	case *ast.HardBreak:
+		return lineBreak
+	case *ast.Text:
+		return Escape(inline.Content)
+	}
+
+	return ""
+}
+
+// 強制改行
+// テキストの\はエスケープするため、出力の\\は強制改行だけになる
+const lineBreak = "\\\\\n"
+
+var escaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`#`, `\#`,
+	`$`, `\$`,
+	`%`, `\%`,
+	`&`, `\&`,
+	`_`, `\_`,
+	`^`, `\textasciicircum{}`,
+	`~`, `\textasciitilde{}`,
+)
+
+// LaTeXの特殊文字をエスケープする
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,104 @@
+package latex
+
+import "testing"
+
+func TestTeX(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"# a\n## b\n### c\n#### d\n###### e\n",
+			"\\section{a}\n\n\\subsection{b}\n\n\\subsubsection{c}\n\n\\paragraph{d}\n\n\\subparagraph{e}\n",
+		},
+		{
+			"*a* **b** ***c*** ~~d~~ `e_f`\n",
+			"\\emph{a} \\textbf{b} \\emph{\\textbf{c}} \\sout{d} \\texttt{e\\_f}\n",
+		},
+		{
+			"soft\nbreak  \nhard\n",
+			"soft\nbreak\\\\\nhard\n",
+		},
+		{
+			"a\\\nb\n===\n\n*c*  \nd\n---\n",
+			"\\section{a b}\n\n\\subsection{\\emph{c} d}\n",
+		},
+		{
+			"\\\n\\\nb\\\nc\n\n- \\\n  x\n",
+			"b\\\\\nc\n\n\\begin{itemize}\n  \\item x\n\\end{itemize}\n",
+		},
+		{
+			"- a\n- b\n---\n",
+			"\\begin{itemize}\n  \\item a\n  \\item b\n\\end{itemize}\n\n\\hrule\n",
+		},
+		{
+			"- a\n  - b\n\n  c\n",
+			"\\begin{itemize}\n  \\item a\n\\begin{itemize}\n  \\item b\n\\end{itemize}\n\nc\n\\end{itemize}\n",
+		},
+		{
+			"```go title=a_b.go\nx\n```\n",
+			"\\begin{lstlisting}[title={a\\_b.go}]\nx\n\\end{lstlisting}\n",
+		},
+		{
+			"```go\nx := `a`\n```\n```\n$ ls\n```\n",
+			"\\begin{lstlisting}\nx := `a`\n\\end{lstlisting}\n\n\\begin{verbatim}\n$ ls\n\\end{verbatim}\n",
+		},
+		{
+			"```Python\nx\n```\n```c]{,\nx\n```\n",
+			"\\begin{lstlisting}[language=Python]\nx\n\\end{lstlisting}\n\n\\begin{lstlisting}\nx\n\\end{lstlisting}\n",
+		},
+		{
+			"```\n\\end{verbatim}\n```\n```go\n\\end{lstlisting}\n```\n",
+			"\\begin{lstlisting}\n\\end{verbatim}\n\\end{lstlisting}\n\n\\begin{verbatim}\n\\end{lstlisting}\n\\end{verbatim}\n",
+		},
+		{
+			"```go title=t\n\\end{lstlisting}\n\n  \\end{verbatim} %\n[x]\n```\n",
+			"\\noindent\\textbf{t}\n\n{\\ttfamily\\noindent\n\\textbackslash{}end\\{lstlisting\\}\\\\\n\\mbox{}\\\\\n~~\\textbackslash{}end\\{verbatim\\}~\\%\\\\\n{}[x]\\par}\n",
+		},
+		{
+			"a[^1] b[^2]\n\nc[^1]\n\n[^1]: one\n[^2]: *two*\n",
+			"a\\footnotemark[1] b\\footnotemark[2]\n\\footnotetext[1]{one}\n\\footnotetext[2]{\\emph{two}}\n\nc\\footnotemark[1]\n",
+		},
+		{
+			"100% & $5 {a} \\b c^2 x_1\n",
+			"100\\% \\& \\$5 \\{a\\} \\textbackslash{}b c\\textasciicircum{}2 x\\_1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := TeX(tt.input, &Renderer{})
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	input := `\{}#$%&_^~`
+	expected := `\textbackslash{}\{\}\#\$\%\&\_\textasciicircum{}\textasciitilde{}`
+
+	actual := Escape(input)
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+}
+
+func TestTeXStandalone(t *testing.T) {
+	input := "---\ntitle: A & B\nauthor: me\n---\ntext\n"
+	expected := "\\documentclass{article}\n" +
+		"\\usepackage[T1]{fontenc}\n" +
+		"\\usepackage[utf8]{inputenc}\n" +
+		"\\usepackage[normalem]{ulem}\n" +
+		"\\usepackage{listings}\n" +
+		"\\title{A \\& B}\n" +
+		"\\author{me}\n" +
+		"\n\\begin{document}\n" +
+		"\\maketitle\n" +
+		"\ntext\n" +
+		"\n\\end{document}\n"
+
+	actual := TeX(input, &Renderer{Standalone: true})
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+}
@@ -9,11 +9,13 @@ type Lexer struct {
 	readPosition int    // 現在の文字の次の文字
 	ch           byte
 	ch_debug     string // デバッグ用のch
+	line         int    // 現在の文字の行番号
+	column       int    // 現在の文字の列番号
 }
 
 // Markdown文書からレキサーを生成
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
@@ -23,13 +25,19 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.skipCarriageReturn()
 
+	line, column := l.line, l.column
+
 	switch l.ch {
 	case '#':
 		tok = newToken(token.IGETA, l.ch)
 	case ' ':
 		tok = newToken(token.SPACE, l.ch)
+	case '\t':
+		tok = newToken(token.TAB, l.ch)
 	case '*':
 		tok = newToken(token.ASTERISK, l.ch)
+	case '_':
+		tok = newToken(token.UNDERSCORE, l.ch)
 	case '-':
 		tok = newToken(token.HYPHEN, l.ch)
 	case '`':
@@ -42,19 +50,28 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Type = token.EOF
 		tok.Literal = ""
 	default:
-		ch := l.ch
-		literal := string(ch) + l.readText()
+		// 先頭の1バイトだけを文字にすると、マルチバイト文字が壊れるため、入力から切り出す
+		position := l.position
+		l.readText()
 		tok.Type = token.TEXT
-		tok.Literal = literal
+		tok.Literal = l.input[position:l.position]
+		tok.Line, tok.Column = line, column
 		return tok
 	}
 
+	tok.Line, tok.Column = line, column
 	l.readChar()
 	return tok
 }
 
 // 次の１文字を読み込んで、inputの現在位置を進める
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 		l.ch_debug = string(l.ch)
@@ -92,7 +109,7 @@ func (l *Lexer) readText() string {
 	position := l.position + 1
 	for {
 		l.readChar()
-		if l.ch == '\n' || l.ch == '\r' || l.ch == 0 || l.ch == ' ' || l.ch == '#' || l.ch == '*' || l.ch == '-' || l.ch == '`' || l.ch == '~' {
+		if l.ch == '\n' || l.ch == '\r' || l.ch == 0 || l.ch == ' ' || l.ch == '\t' || l.ch == '#' || l.ch == '*' || l.ch == '_' || l.ch == '-' || l.ch == '`' || l.ch == '~' {
 			break
 		}
 	}
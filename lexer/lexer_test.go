@@ -225,3 +225,145 @@ func TestCodeBlock(t *testing.T) {
 	}
 
 }
+
+// 字下げのタブは空白と同じく1文字ずつのトークンにする
+func TestTab(t *testing.T) {
+	input := "\t \tcode\tx"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TAB, "\t"},
+		{token.SPACE, " "},
+		{token.TAB, "\t"},
+		{token.TEXT, "code"},
+		{token.TAB, "\t"},
+		{token.TEXT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] = tokenType wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+// 強調に使う_は、単語の途中でも1文字ずつのトークンにする
+func TestUnderscore(t *testing.T) {
+	input := "snake_case __a__"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEXT, "snake"},
+		{token.UNDERSCORE, "_"},
+		{token.TEXT, "case"},
+		{token.SPACE, " "},
+		{token.UNDERSCORE, "_"},
+		{token.UNDERSCORE, "_"},
+		{token.TEXT, "a"},
+		{token.UNDERSCORE, "_"},
+		{token.UNDERSCORE, "_"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] = tokenType wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+// 先頭がマルチバイト文字のテキスト
+func TestMultibyteText(t *testing.T) {
+	input := "日本語 *é*"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEXT, "日本語"},
+		{token.SPACE, " "},
+		{token.ASTERISK, "*"},
+		{token.TEXT, "é"},
+		{token.ASTERISK, "*"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] = tokenType wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPosition(t *testing.T) {
+	input := "# h1\n\n*em* text\r\n- a"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.IGETA, 1, 1},
+		{token.SPACE, 1, 2},
+		{token.TEXT, 1, 3},
+		{token.CR, 1, 5},
+		{token.CR, 2, 1},
+		{token.ASTERISK, 3, 1},
+		{token.TEXT, 3, 2},
+		{token.ASTERISK, 3, 4},
+		{token.SPACE, 3, 5},
+		{token.TEXT, 3, 6},
+		{token.CR, 3, 11},
+		{token.HYPHEN, 4, 1},
+		{token.SPACE, 4, 2},
+		{token.TEXT, 4, 3},
+		{token.EOF, 4, 4},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] = tokenType wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package lint
+
+import (
+	"encoding/json"
+	"godown/ast"
+	"godown/lexer"
+	"godown/parser"
+	"godown/token"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 既定の1行の最大文字数
+const DefaultMaxLineLength = 80
+
+// 検査で見つかった問題
+type Problem struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// 検査対象の文書
+type Source struct {
+	Lines    []string      // 改行を除いた各行
+	Code     []bool        // 各行がコードブロック(フェンスを含む)の中かどうか
+	Document *ast.Document // 構文解析したAST
+}
+
+// 文書を検査するルール
+type Rule interface {
+	Name() string        // 設定やコメントで指定するルール名
+	Description() string // ルールの説明
+	Check(src *Source, cfg *Config) []Problem
+}
+
+// 登録されたルール
+var rules = map[string]Rule{}
+
+// ルールを登録する
+func Register(r Rule) {
+	rules[r.Name()] = r
+}
+
+// 登録されたルールを名前の順に返す
+func Rules() []Rule {
+	var list []Rule
+	for _, r := range rules {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// 検査の設定
+type Config struct {
+	// ルールごとの有効・無効(指定のないルールは有効)
+	Rules map[string]bool `json:"rules"`
+	// line-lengthルールの1行の最大文字数
+	MaxLineLength int `json:"max-line-length"`
+}
+
+// 既定の設定
+func DefaultConfig() *Config {
+	return &Config{Rules: map[string]bool{}, MaxLineLength: DefaultMaxLineLength}
+}
+
+// JSON形式の設定ファイルを読み込む
+func LoadConfig(name string) (*Config, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = map[string]bool{}
+	}
+
+	return cfg, nil
+}
+
+// ルールが有効かどうか
+func (c *Config) Enabled(rule string) bool {
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+// 文書を解析する
+func NewSource(input string) *Source {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+
+	src := &Source{Lines: strings.Split(strings.TrimSuffix(input, "\n"), "\n")}
+
+	// パーサはHTMLを扱えないため、コメントで始まる行(HTMLブロック)は空行にし、
+	// 行の途中のコメントは列の位置を保つよう同じ長さの空白に置き換える
+	stripped := htmlBlock.ReplaceAllString(input, "")
+	stripped = comment.ReplaceAllStringFunc(stripped, func(s string) string {
+		return strings.Repeat(" ", len(s))
+	})
+	l := lexer.New(stripped)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions|parser.FrontMatter)
+	src.Document = p.ParseDocument()
+
+	src.Code = make([]bool, len(src.Lines))
+	ast.Inspect(src.Document, func(node ast.Node) bool {
+		block, ok := node.(*ast.CodeBlock)
+		if !ok {
+			return true
+		}
+
+		start, end := codeBlockLines(block)
+		for line := start; line <= end && line <= len(src.Lines); line++ {
+			src.Code[line-1] = true
+		}
+		return false
+	})
+
+	return src
+}
+
+// コードブロックの最初と最後の行(フェンスを含む)
+// フェンスで囲まれたコードブロックは、最後の改行の次の行に閉じるフェンスがある
+func codeBlockLines(block *ast.CodeBlock) (int, int) {
+	start := block.Token.Line
+	if len(block.Contents) == 0 {
+		if block.Indented {
+			return start, start
+		}
+		return start, start + 1
+	}
+
+	last, ok := block.Contents[len(block.Contents)-1].(*ast.Text)
+	if !ok {
+		return start, start
+	}
+
+	end := last.Token.Line
+	if !block.Indented && last.Token.Type == token.CR {
+		end++
+	}
+	return start, end
+}
+
+// 有効なルールで文書を検査し、問題を行の順に返す
+// <!-- godown-disable rule --> から <!-- godown-enable rule --> までの行では
+// 指定したルールの問題を報告しない(ルール名を省略するとすべてのルール)
+func Lint(input string, cfg *Config) []Problem {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	src := NewSource(input)
+	disabled := disabledLines(src)
+
+	var problems []Problem
+	for _, r := range Rules() {
+		if !cfg.Enabled(r.Name()) {
+			continue
+		}
+
+		for _, problem := range r.Check(src, cfg) {
+			problem.Rule = r.Name()
+			if disabled.contains(problem.Line, problem.Rule) {
+				continue
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+
+	return problems
+}
+
+var (
+	// 1行のHTMLのコメント
+	comment = regexp.MustCompile(`<!--.*?-->`)
+	// 1行のコメントで始まる行
+	htmlBlock = regexp.MustCompile(`(?m)^ {0,3}<!--.*?-->.*$`)
+	// 検査を抑制するコメント
+	directive = regexp.MustCompile(`<!--\s*godown-(disable|enable)((?:\s+[\w-]+)*)\s*-->`)
+)
+
+// 行ごとに抑制されたルール
+// "*"はすべてのルールを表す
+type suppressions []map[string]bool
+
+func (s suppressions) contains(line int, rule string) bool {
+	if line < 1 || line > len(s) {
+		return false
+	}
+	return s[line-1]["*"] || s[line-1][rule]
+}
+
+func disabledLines(src *Source) suppressions {
+	lines := make(suppressions, len(src.Lines))
+
+	current := map[string]bool{}
+	for i, line := range src.Lines {
+		if !src.Code[i] {
+			for _, m := range directive.FindAllStringSubmatch(line, -1) {
+				names := strings.Fields(m[2])
+				if len(names) == 0 {
+					names = []string{"*"}
+				}
+
+				next := map[string]bool{}
+				for name := range current {
+					next[name] = true
+				}
+				for _, name := range names {
+					if m[1] == "disable" {
+						next[name] = true
+					} else if name == "*" {
+						next = map[string]bool{}
+					} else {
+						delete(next, name)
+					}
+				}
+				current = next
+			}
+		}
+
+		lines[i] = current
+	}
+
+	return lines
+}
@@ -0,0 +1,92 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 問題を"行:列 ルール"の形式にする
+func summarize(problems []Problem) []string {
+	var list []string
+	for _, p := range problems {
+		list = append(list, fmt.Sprintf("%d:%d %s", p.Line, p.Column, p.Rule))
+	}
+	return list
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"# a\n### b\n## c\n#### d\n", []string{"2:1 heading-increment", "4:1 heading-increment"}},
+		{"# a\n\n# b\n", []string{"3:1 single-h1"}},
+		{"# a\ntext \ntext  \n```go\ncode  \n```\n", []string{"2:5 no-trailing-spaces", "5:5 no-trailing-spaces"}},
+		{"# a\n[](x.md) [a]() [b](#) [ok](y.md) `[](z)`\n", []string{
+			"2:1 no-empty-links", "2:10 no-empty-links", "2:16 no-empty-links"}},
+		{"# a\n*a* **b\nc** ***d*** ~~e~~\n", nil},
+		{"# a\n*a **b* c\n\nd**\n", []string{
+			"2:1 no-unclosed-emphasis", "2:4 no-unclosed-emphasis", "4:2 no-unclosed-emphasis"}},
+		{"# a\n_a snake_case a * b\n", []string{"2:1 no-unclosed-emphasis"}},
+		{"# a\n\n    *code\n    " + strings.Repeat("c", 90) + "\n", nil},
+		{"# a\n~~a `*b`\n", []string{"2:1 no-unclosed-emphasis"}},
+		{"# a\na <!-- x --> *b\n", []string{"2:14 no-unclosed-emphasis"}},
+		{"# a\n<!-- x -->\n\n*a* <!-- y --> <!-- z --> ~~b\n", []string{"4:27 no-unclosed-emphasis"}},
+		{"# a\n<!-- x --> *a\n", nil},
+		{"# a\n```\ncode\n```\n```go\ncode\n```\n", []string{"2:1 fenced-code-language"}},
+		{"# a\n```{linenos=true}\ncode\n```\n```go {hl=1}\ncode\n```\n", []string{"2:1 fenced-code-language"}},
+		{"# A b\n## a-b\n## A B\n", []string{"2:1 no-duplicate-heading-ids", "3:1 no-duplicate-heading-ids"}},
+		{"# a\n" + strings.Repeat("a", 81) + "\n```go\n" + strings.Repeat("b", 90) + "\n```\n", []string{"2:81 line-length"}},
+	}
+
+	for _, tt := range tests {
+		actual := summarize(Lint(tt.input, nil))
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("input=%q wrong. expected=%v, got=%v", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestDisableComments(t *testing.T) {
+	input := "# a\n" +
+		"<!-- godown-disable single-h1 no-trailing-spaces -->\n" +
+		"# b \n" +
+		"<!-- godown-enable no-trailing-spaces -->\n" +
+		"# c \n" +
+		"<!-- godown-disable -->\n" +
+		"# d \n" +
+		"<!-- godown-enable -->\n" +
+		"# e\n"
+
+	expected := []string{"5:4 no-trailing-spaces", "9:1 single-h1"}
+
+	actual := summarize(Lint(input, nil))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("wrong. expected=%v, got=%v", expected, actual)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "godownlint.json")
+	err := os.WriteFile(name, []byte(`{"rules": {"single-h1": false}, "max-line-length": 10}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "# a\n# b\nabcdefghijk\n"
+	expected := []string{"3:11 line-length"}
+
+	actual := summarize(Lint(input, cfg))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("wrong. expected=%v, got=%v", expected, actual)
+	}
+}
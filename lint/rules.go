@@ -0,0 +1,361 @@
+package lint
+
+import (
+	"fmt"
+	"godown/ast"
+	"godown/parser"
+	"godown/token"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(headingIncrement{})
+	Register(singleH1{})
+	Register(noTrailingSpaces{})
+	Register(noEmptyLinks{})
+	Register(noUnclosedEmphasis{})
+	Register(fencedCodeLanguage{})
+	Register(noDuplicateHeadingIDs{})
+	Register(lineLength{})
+}
+
+// 文書中の見出し
+func headings(src *Source) []*ast.Heading {
+	var list []*ast.Heading
+	for _, block := range src.Document.Blocks {
+		if heading, ok := block.(*ast.Heading); ok {
+			list = append(list, heading)
+		}
+	}
+	return list
+}
+
+// 見出しのレベルは1つずつ深くする
+type headingIncrement struct{}
+
+func (headingIncrement) Name() string        { return "heading-increment" }
+func (headingIncrement) Description() string { return "heading levels should only increment by one" }
+func (headingIncrement) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	prev := 0
+	for _, h := range headings(src) {
+		if prev > 0 && h.Level > prev+1 {
+			problems = append(problems, Problem{
+				Line:    h.Token.Line,
+				Column:  h.Token.Column,
+				Message: fmt.Sprintf("heading level jumps from h%d to h%d", prev, h.Level),
+			})
+		}
+		prev = h.Level
+	}
+
+	return problems
+}
+
+// h1は文書に1つだけ
+type singleH1 struct{}
+
+func (singleH1) Name() string        { return "single-h1" }
+func (singleH1) Description() string { return "a document should have only one h1" }
+func (singleH1) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	first := 0
+	for _, h := range headings(src) {
+		if h.Level != 1 {
+			continue
+		}
+		if first == 0 {
+			first = h.Token.Line
+			continue
+		}
+		problems = append(problems, Problem{
+			Line:    h.Token.Line,
+			Column:  h.Token.Column,
+			Message: fmt.Sprintf("multiple h1 headings (first on line %d)", first),
+		})
+	}
+
+	return problems
+}
+
+// 行末の空白を禁止する
+// コードブロックの外では、改行を表す2つの空白は許可する
+type noTrailingSpaces struct{}
+
+func (noTrailingSpaces) Name() string        { return "no-trailing-spaces" }
+func (noTrailingSpaces) Description() string { return "lines should not end with whitespace" }
+func (noTrailingSpaces) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	for i, line := range src.Lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == line {
+			continue
+		}
+		if !src.Code[i] && trimmed != "" && line[len(trimmed):] == "  " {
+			continue
+		}
+		problems = append(problems, Problem{
+			Line:    i + 1,
+			Column:  len(trimmed) + 1,
+			Message: "trailing whitespace",
+		})
+	}
+
+	return problems
+}
+
+var (
+	inlineCode = regexp.MustCompile("`[^`]*`")
+	link       = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+)
+
+// インラインコードを同じ長さの空白に置き換える
+func stripInlineCode(line string) string {
+	return inlineCode.ReplaceAllStringFunc(line, func(s string) string {
+		return strings.Repeat(" ", len(s))
+	})
+}
+
+// リンクのテキストとURLを空にしない
+type noEmptyLinks struct{}
+
+func (noEmptyLinks) Name() string        { return "no-empty-links" }
+func (noEmptyLinks) Description() string { return "links should have text and a destination" }
+func (noEmptyLinks) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	for i, line := range src.Lines {
+		if src.Code[i] {
+			continue
+		}
+
+		for _, m := range link.FindAllStringSubmatchIndex(stripInlineCode(line), -1) {
+			text := strings.TrimSpace(line[m[2]:m[3]])
+			dest := strings.TrimSpace(line[m[4]:m[5]])
+
+			var message string
+			switch {
+			case text == "":
+				message = "link has no text"
+			case dest == "" || dest == "#":
+				message = "link has no destination"
+			default:
+				continue
+			}
+			problems = append(problems, Problem{Line: i + 1, Column: m[0] + 1, Message: message})
+		}
+	}
+
+	return problems
+}
+
+// 強調と打ち消しの記号を閉じ忘れない
+// パーサが強調にできずにテキストとして残した区切り文字の並びを報告する
+type noUnclosedEmphasis struct{}
+
+func (noUnclosedEmphasis) Name() string { return "no-unclosed-emphasis" }
+func (noUnclosedEmphasis) Description() string {
+	return "emphasis and strikethrough markers should be closed"
+}
+func (noUnclosedEmphasis) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	var check func(inlines []ast.Inline, edge rune)
+	check = func(inlines []ast.Inline, edge rune) {
+		for i, inline := range inlines {
+			switch inline := inline.(type) {
+			case *ast.Emphasis:
+				marker, _ := utf8.DecodeRuneInString(inline.Token.Literal)
+				check(inline.Contents, marker)
+			case *ast.Strikethrough:
+				check(inline.Contents, '~')
+			case *ast.Text:
+				if message := unclosedMessage(inline, runeBefore(inlines, i, edge), runeAfter(inlines, i, edge)); message != "" {
+					problems = append(problems, Problem{
+						Line:    inline.Token.Line,
+						Column:  inline.Token.Column,
+						Message: message,
+					})
+				}
+			}
+		}
+	}
+
+	ast.Inspect(src.Document, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Heading:
+			check(node.Contents, '\n')
+		case *ast.Paragraph:
+			check(node.Contents, '\n')
+		case *ast.DiscList:
+			for _, item := range node.Lists {
+				check(item, '\n')
+			}
+			// 項目の続きのブロックも調べる
+			return true
+		case *ast.Document, *ast.Footnote:
+			return true
+		}
+		return false
+	})
+
+	return problems
+}
+
+// 強調にならなかった区切り文字の並びの問題
+// 強調を開始も終了もできない並び(a * bやsnake_case)と、~~以外の~の並びは問題にしない
+func unclosedMessage(text *ast.Text, before, after rune) string {
+	switch text.Token.Type {
+	case token.ASTERISK, token.UNDERSCORE:
+		canOpen, canClose := parser.Flanking(text.Content[0], before, after)
+		switch {
+		case canOpen:
+			return fmt.Sprintf("unclosed %q", text.Content)
+		case canClose:
+			return fmt.Sprintf("%q closes nothing", text.Content)
+		}
+	case token.TILDE:
+		if text.Content == "~~" {
+			return fmt.Sprintf("unclosed %q", text.Content)
+		}
+	}
+
+	return ""
+}
+
+// inlines[i]の直前の文字(先頭の場合はedge)
+func runeBefore(inlines []ast.Inline, i int, edge rune) rune {
+	if i == 0 {
+		return edge
+	}
+
+	switch inline := inlines[i-1].(type) {
+	case *ast.Text:
+		r, _ := utf8.DecodeLastRuneInString(inline.Content)
+		return r
+	case *ast.Emphasis:
+		r, _ := utf8.DecodeRuneInString(inline.Token.Literal)
+		return r
+	case *ast.InlineCode:
+		return '`'
+	case *ast.Strikethrough:
+		return '~'
+	case *ast.FootnoteReference:
+		return ']'
+	}
+
+	return '\n'
+}
+
+// inlines[i]の直後の文字(末尾の場合はedge)
+func runeAfter(inlines []ast.Inline, i int, edge rune) rune {
+	if i == len(inlines)-1 {
+		return edge
+	}
+
+	switch inline := inlines[i+1].(type) {
+	case *ast.Text:
+		r, _ := utf8.DecodeRuneInString(inline.Content)
+		return r
+	case *ast.Emphasis:
+		r, _ := utf8.DecodeRuneInString(inline.Token.Literal)
+		return r
+	case *ast.InlineCode:
+		return '`'
+	case *ast.Strikethrough:
+		return '~'
+	case *ast.FootnoteReference:
+		return '['
+	}
+
+	return '\n'
+}
+
+// コードブロックには言語を指定する
+type fencedCodeLanguage struct{}
+
+func (fencedCodeLanguage) Name() string        { return "fenced-code-language" }
+func (fencedCodeLanguage) Description() string { return "fenced code blocks should specify a language" }
+func (fencedCodeLanguage) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	ast.Inspect(src.Document, func(node ast.Node) bool {
+		block, ok := node.(*ast.CodeBlock)
+		if !ok {
+			return true
+		}
+		if !block.Indented && block.Lang == nil {
+			problems = append(problems, Problem{
+				Line:    block.Token.Line,
+				Column:  block.Token.Column,
+				Message: "code block has no language",
+			})
+		}
+		return false
+	})
+
+	return problems
+}
+
+// 見出しから生成されるidを重複させない
+type noDuplicateHeadingIDs struct{}
+
+func (noDuplicateHeadingIDs) Name() string        { return "no-duplicate-heading-ids" }
+func (noDuplicateHeadingIDs) Description() string { return "headings should produce unique anchor ids" }
+func (noDuplicateHeadingIDs) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	seen := map[string]int{}
+	for _, h := range headings(src) {
+		id := ast.Slug(ast.PlainText(h))
+		if id == "" {
+			continue
+		}
+		if line, ok := seen[id]; ok {
+			problems = append(problems, Problem{
+				Line:    h.Token.Line,
+				Column:  h.Token.Column,
+				Message: fmt.Sprintf("duplicate heading id %q (first on line %d)", id, line),
+			})
+			continue
+		}
+		seen[id] = h.Token.Line
+	}
+
+	return problems
+}
+
+// 長すぎる行を禁止する
+// コードブロックの中の行は検査しない
+type lineLength struct{}
+
+func (lineLength) Name() string        { return "line-length" }
+func (lineLength) Description() string { return "lines should not be longer than max-line-length" }
+func (lineLength) Check(src *Source, cfg *Config) []Problem {
+	var problems []Problem
+
+	max := cfg.MaxLineLength
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+
+	for i, line := range src.Lines {
+		if src.Code[i] {
+			continue
+		}
+		if n := utf8.RuneCountInString(line); n > max {
+			problems = append(problems, Problem{
+				Line:    i + 1,
+				Column:  max + 1,
+				Message: fmt.Sprintf("line is %d characters long (max %d)", n, max),
+			})
+		}
+	}
+
+	return problems
+}
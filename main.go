@@ -1,7 +1,16 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"godown/ansi"
 	"godown/converter"
+	"godown/latex"
+	"godown/parser"
+	"godown/plaintext"
+	"godown/repl"
+	"godown/roff"
 	"os"
 	"os/user"
 )
@@ -12,5 +21,113 @@ func main() {
 		panic(err)
 	}
 
-	converter.Convert(os.Stdin, os.Stdout)
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "godown:", err)
+		os.Exit(1)
+	}
+}
+
+// サブコマンドを実行する
+// サブコマンドがない場合は標準入力を変換して標準出力に書き出す
+func run(args []string) error {
+	if len(args) == 0 {
+		return runConvert(args)
+	}
+
+	switch args[0] {
+	case "batch":
+		return runBatch(args[1:])
+	case "watch":
+		return runWatch(args[1:])
+	case "serve":
+		return runServe(args[1:])
+	case "site":
+		return runSite(args[1:])
+	case "fmt":
+		return runFmt(args[1:])
+	case "lint":
+		return runLint(args[1:])
+	case "check":
+		return runCheck(args[1:])
+	case "tangle":
+		return runTangle(args[1:])
+	case "view":
+		return runView(args[1:])
+	case "ast":
+		return runAST(args[1:])
+	case "repl":
+		repl.Start(os.Stdin, os.Stdout)
+		return nil
+	default:
+		return runConvert(args)
+	}
+}
+
+// 標準入力を変換する
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("godown", flag.ExitOnError)
+	options := optionFlags(fs)
+	format := fs.String("format", "html", "output `format` (html, text, ansi, man or latex)")
+	wrap := fs.Int("wrap", 0, "wrap text output at this width (0 means no wrapping)")
+	standalone := fs.Bool("standalone", false, "output a complete LaTeX document with a preamble")
+	fs.Parse(args)
+	opts := options()
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	r, err := renderer(*format, *wrap, *standalone)
+	if err != nil {
+		return err
+	}
+	opts.Renderer = r
+	if *format == "man" || *format == "latex" {
+		// タイトルなどをフロントマターから読み込む
+		opts.Extensions |= parser.FrontMatter
+	}
+
+	return converter.Convert(context.Background(), os.Stdin, os.Stdout, opts)
+}
+
+// 出力形式に対応するレンダラーを返す
+// HTMLの場合はnilを返す
+func renderer(format string, wrap int, standalone bool) (converter.Renderer, error) {
+	switch format {
+	case "html":
+		return nil, nil
+	case "text":
+		return &plaintext.Renderer{Wrap: wrap}, nil
+	case "ansi":
+		return &ansi.Renderer{Wrap: wrap}, nil
+	case "man":
+		return &roff.Renderer{}, nil
+	case "latex":
+		return &latex.Renderer{Standalone: standalone}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// 変換の設定をフラグとして登録する
+// 返す関数はフラグを解析した後に呼び、解析した設定を返す
+func optionFlags(fs *flag.FlagSet) func() converter.Options {
+	opts := converter.DefaultOptions()
+
+	fs.StringVar(&opts.Theme, "theme", "", "CSS theme `file` embedded in the HTML output")
+	fs.BoolVar(&opts.Safe, "safe", false, "escape raw HTML in the input")
+	fs.BoolVar(&opts.CopyButton, "copy-button", false, "add a copy button to code blocks in the HTML output")
+	hardBreaks := fs.Bool("hard-breaks", false, "render every line break inside a paragraph as a hard break")
+	frontMatter := fs.Bool("front-matter", false, "read metadata between --- lines at the start of the input instead of rendering it")
+	fs.Int64Var(&opts.MaxInputSize, "max-size", 0, "maximum input size in bytes (0 means unlimited)")
+
+	return func() converter.Options {
+		if *hardBreaks {
+			opts.Extensions |= parser.HardLineBreaks
+		}
+		if *frontMatter {
+			opts.Extensions |= parser.FrontMatter
+		}
+		return opts
+	}
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"godown/parser"
+	"testing"
+)
+
+func TestOptionFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected parser.Extensions
+	}{
+		{nil, parser.CommonExtensions},
+		{[]string{"-hard-breaks"}, parser.CommonExtensions | parser.HardLineBreaks},
+		{[]string{"-hard-breaks=true"}, parser.CommonExtensions | parser.HardLineBreaks},
+		{[]string{"-hard-breaks=false"}, parser.CommonExtensions},
+		{[]string{"-front-matter"}, parser.CommonExtensions | parser.FrontMatter},
+		{[]string{"-front-matter=false"}, parser.CommonExtensions},
+		{[]string{"-front-matter", "-hard-breaks=false"}, parser.CommonExtensions | parser.FrontMatter},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		options := optionFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("args=%q: %v", tt.args, err)
+		}
+
+		actual := options().Extensions
+		if actual != tt.expected {
+			t.Errorf("args=%q wrong. expected=%b, got=%b", tt.args, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package markdown
+
+import (
+	"bytes"
+	"godown/ast"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ASTを正規化したMarkdownとして出力するレンダラー
+//   - 見出しはATX形式(#)
+//   - リストの記号は-
+//   - コードブロックはバッククォートで囲む(字下げによるコードブロックは字下げのまま)
+//   - 強調は元の区切り文字(*か_)、打ち消しは~~
+//   - 脚注の定義は番号の順に文書の最後に置く
+//   - 表(GFM)は列の幅を揃える
+type Renderer struct {
+	Wrap int // パラグラフを折り返す幅(0の場合は折り返さない)
+}
+
+// srcを正規化したMarkdownに整形する
+func Format(src string, r *Renderer) string {
+	l := lexer.New(src)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions|parser.FrontMatter)
+	document := p.ParseDocument()
+
+	var out bytes.Buffer
+	r.Render(&out, document)
+
+	return out.String()
+}
+
+func (r *Renderer) Render(w io.Writer, doc *ast.Document) error {
+	var out bytes.Buffer
+
+	writeFrontMatter(&out, doc.FrontMatter)
+
+	for i, block := range doc.Blocks {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		r.writeBlock(&out, block)
+	}
+	r.writeFootnotes(&out, doc.Footnotes)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// 脚注の定義を出力する
+// 2行目以降は4つの空白で字下げする
+func (r *Renderer) writeFootnotes(out *bytes.Buffer, footnotes []*ast.Footnote) {
+	for _, footnote := range footnotes {
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+
+		var body bytes.Buffer
+		for i, block := range footnote.Blocks {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			r.writeBlock(&body, block)
+		}
+
+		out.WriteString("[^" + footnote.Label + "]:")
+		if len(footnote.Blocks) > 0 {
+			for i, line := range strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n") {
+				switch {
+				case i == 0 && isParagraph(footnote.Blocks[0]):
+					out.WriteString(" " + line)
+				case line != "":
+					out.WriteString("\n    " + line)
+				default:
+					out.WriteString("\n")
+				}
+			}
+		}
+		out.WriteString("\n")
+	}
+}
+
+func isParagraph(block ast.Block) bool {
+	_, ok := block.(*ast.Paragraph)
+	return ok
+}
+
+// フロントマターをキーの順に出力する
+func writeFrontMatter(out *bytes.Buffer, frontMatter map[string]string) {
+	if frontMatter == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(frontMatter))
+	for key := range frontMatter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out.WriteString("---\n")
+	for _, key := range keys {
+		value := frontMatter[key]
+		if value != strings.TrimSpace(value) || strings.ContainsAny(value, "\"':#") {
+			value = strconv.Quote(value)
+		}
+		out.WriteString(key + ": " + value + "\n")
+	}
+	out.WriteString("---\n\n")
+}
+
+func (r *Renderer) writeBlock(out *bytes.Buffer, block ast.Block) {
+	switch block := block.(type) {
+	case *ast.Heading:
+		out.WriteString(strings.Repeat("#", block.Level))
+		out.WriteString(" ")
+		out.WriteString(strings.TrimSpace(singleLine.Replace(Inlines(block.Contents))))
+		out.WriteString("\n")
+	case *ast.DiscList:
+		for i, item := range block.Lists {
+			out.WriteString("- ")
+			out.WriteString(strings.ReplaceAll(strings.TrimSpace(Inlines(item)), "\n", "\n  "))
+			out.WriteString("\n")
+			r.writeItemBlocks(out, block.ItemBlocks(i))
+		}
+	case *ast.Paragraph:
+		out.WriteString(r.paragraph(block.Contents))
+		out.WriteString("\n")
+	case *ast.CodeBlock:
+		if block.Indented {
+			writeIndentedCode(out, Inlines(block.Contents))
+			break
+		}
+
+		out.WriteString("```")
+		if block.Lang != nil {
+			out.WriteString(block.Lang.String())
+		}
+		writeAttributes(out, block.Attributes)
+		out.WriteString("\n")
+
+		code := Inlines(block.Contents)
+		out.WriteString(code)
+		if code != "" && !strings.HasSuffix(code, "\n") {
+			out.WriteString("\n")
+		}
+		out.WriteString("```\n")
+	case *ast.HorizontalRule:
+		out.WriteString("---\n")
+	}
+}
+
+// リストの項目の続きのブロックを、項目の内容の位置まで字下げして出力する
+// 入れ子のリスト以外のブロックの前には空行を置く
+func (r *Renderer) writeItemBlocks(out *bytes.Buffer, blocks []ast.Block) {
+	sub := *r
+	if sub.Wrap > 2 {
+		sub.Wrap -= 2
+	}
+
+	for i, block := range blocks {
+		if _, ok := block.(*ast.DiscList); !ok || i > 0 {
+			out.WriteString("\n")
+		}
+
+		var body bytes.Buffer
+		sub.writeBlock(&body, block)
+		for _, line := range strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n") {
+			if line != "" {
+				out.WriteString("  " + line)
+			}
+			out.WriteString("\n")
+		}
+	}
+}
+
+// 字下げによるコードブロックを4つの空白で字下げして出力する
+// コードに```の行があってもフェンスと混同しないように、字下げのまま残す
+func writeIndentedCode(out *bytes.Buffer, code string) {
+	for _, line := range strings.Split(strings.TrimSuffix(code, "\n"), "\n") {
+		if line != "" {
+			out.WriteString("    " + line)
+		}
+		out.WriteString("\n")
+	}
+}
+
+// ATX形式の見出しは1行に収めるため、改行を空白にする
+var singleLine = strings.NewReplacer("\\\n", " ", "\n", " ")
+
+// コードブロックの属性をキーの順に{}で囲んで出力する
+func writeAttributes(out *bytes.Buffer, attributes map[string]string) {
+	if len(attributes) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out.WriteString(" {")
+	for i, key := range keys {
+		if i > 0 {
+			out.WriteString(" ")
+		}
+		value := attributes[key]
+		if value == "" || strings.ContainsAny(value, " \t\"{}=") {
+			value = `"` + value + `"`
+		}
+		out.WriteString(key + "=" + value)
+	}
+	out.WriteString("}")
+}
+
+// パラグラフを出力する
+// Wrapが指定されている場合は、インライン要素の途中では改行しないように単語単位で折り返す
+// ソフト改行は単語の区切りとして扱い、強制改行の位置では必ず改行する
+// 表のパラグラフは折り返さずに列の幅を揃える
+func (r *Renderer) paragraph(contents []ast.Inline) string {
+	if table, ok := formatTable(Inlines(contents)); ok {
+		return table
+	}
+	if r.Wrap <= 0 {
+		return strings.TrimSpace(Inlines(contents))
+	}
+
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, inline := range contents {
+		switch inline := inline.(type) {
+		case *ast.Text:
+			for _, ch := range inline.Content {
+				if ch == ' ' || ch == '\n' {
+					flush()
+					continue
+				}
+				word.WriteRune(ch)
+			}
+		case *ast.SoftBreak:
+			flush()
+		case *ast.HardBreak:
+			flush()
+			words = append(words, hardBreak)
+		default:
+			word.WriteString(Inline(inline))
+		}
+	}
+	flush()
+
+	var out strings.Builder
+	width := 0
+	for _, w := range words {
+		if w == hardBreak {
+			out.WriteString(hardBreak)
+			width = 0
+			continue
+		}
+
+		n := utf8.RuneCountInString(w)
+
+		switch {
+		case width == 0:
+		case width+1+n > r.Wrap && !startsBlock(w):
+			out.WriteString("\n")
+			width = 0
+		default:
+			out.WriteString(" ")
+			width++
+		}
+		out.WriteString(w)
+		width += n
+	}
+
+	return out.String()
+}
+
+// 強制改行はバックスラッシュで表す
+const hardBreak = "\\\n"
+
+// 行頭に置くとブロック要素として解釈される単語かどうか
+func startsBlock(word string) bool {
+	return strings.HasPrefix(word, "#") || strings.HasPrefix(word, "-") || strings.HasPrefix(word, "=") ||
+		strings.Trim(word, "*_") == "" || strings.HasPrefix(word, "```") ||
+		footnoteDefinition.MatchString(word)
+}
+
+// 脚注の定義として解釈される単語
+var footnoteDefinition = regexp.MustCompile(`^\[\^[^\]\s]+\]:`)
+
+// インライン要素の列をMarkdownにする
+func Inlines(inlines []ast.Inline) string {
+	var out strings.Builder
+
+	for _, inline := range inlines {
+		out.WriteString(Inline(inline))
+	}
+
+	return out.String()
+}
+
+// 強調の開始側と終了側の区切り文字
+// 隣り合う強調(*a*_b_)がつながらないように、元の文書の区切り文字を使う
+// 元の区切り文字が分からない場合は*にする
+func emphasisMarkers(emphasis *ast.Emphasis) (string, string) {
+	open := emphasis.Token.Literal
+	if len(open) != emphasis.Level || strings.Trim(open, "*_") != "" {
+		open = strings.Repeat("*", emphasis.Level)
+	}
+
+	close := []byte(open)
+	for i, j := 0, len(close)-1; i < j; i, j = i+1, j-1 {
+		close[i], close[j] = close[j], close[i]
+	}
+
+	return open, string(close)
+}
+
+// インライン要素をMarkdownにする
+func Inline(inline ast.Inline) string {
+	switch inline := inline.(type) {
+	case *ast.Emphasis:
+		open, close := emphasisMarkers(inline)
+		return open + Inlines(inline.Contents) + close
+	case *ast.InlineCode:
+		return "`" + Inlines(inline.Contents) + "`"
+	case *ast.Strikethrough:
+		return "~~" + Inlines(inline.Contents) + "~~"
+	case *ast.FootnoteReference:
+		return "[^" + inline.Label + "]"
+	case *ast.SoftBreak:
+		return "\n"
+	case *ast.HardBreak:
+		return hardBreak
+	case *ast.Text:
+		return inline.Content
+	}
+
+	return ""
+}
@@ -0,0 +1,116 @@
+package markdown
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"#   heading  \n",
+			"# heading\n",
+		},
+		{
+			"| a | 日本 |\n|:-|-:|\n|longer| x\\|y |\n| z |\n",
+			"| a      | 日本 |\n| :----- | ---: |\n| longer | x\\|y |\n| z      |      |\n",
+		},
+		{
+			"x|y|z\n-|:-:|-\n1|2|3|4\n",
+			"| x   |  y  | z   |\n| --- | :-: | --- |\n| 1   |  2  | 3   | 4 |\n",
+		},
+		{
+			"a | b\nnot a delimiter\n",
+			"a | b\nnot a delimiter\n",
+		},
+		{
+			"- a\n    - b\n-  c\n\n   text\n\n       code\n- d\n",
+			"- a\n  - b\n- c\n\n  text\n\n      code\n- d\n",
+		},
+		{
+			"## Heading*2*\ntext `code` ~~del~~\n- a\n-   b\n",
+			"## Heading*2*\n\ntext `code` ~~del~~\n\n- a\n- b\n",
+		},
+		{
+			"***a*** **b**\n\n---\n```go\nfunc main() {}\n```\n",
+			"***a*** **b**\n\n---\n\n```go\nfunc main() {}\n```\n",
+		},
+		{
+			"*a*_b_ __c__**d** _**e**_\n",
+			"*a*_b_ __c__**d** _**e**_\n",
+		},
+		{
+			"heading 1\n===\nheading 2\n---\n",
+			"# heading 1\n\n## heading 2\n",
+		},
+		{
+			"```\ncode\n```",
+			"```\ncode\n```\n",
+		},
+		{
+			"text\n\n    code\n\n\t  indented\n",
+			"text\n\n    code\n\n      indented\n",
+		},
+		{
+			"```go   hl=2 title=\"a b\"  linenos\ncode\n```\n",
+			"```go {hl=2 linenos=true title=\"a b\"}\ncode\n```\n",
+		},
+		{
+			"a[^b] c[^d]\n[^d]: d\n[^b]: b\n\n    ```go\n    x\n    ```\n",
+			"a[^b] c[^d]\n\n[^b]: b\n\n    ```go\n    x\n    ```\n\n[^d]: d\n",
+		},
+		{
+			"---\ntitle: a: b\nweight: 1\n---\n# a\n",
+			"---\ntitle: \"a: b\"\nweight: 1\n---\n\n# a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Format(tt.input, &Renderer{})
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatWrap(t *testing.T) {
+	input := "aaa bbb `c c` ccc\neee *f f*  \ng\n"
+	expected := "aaa bbb\n`c c` ccc\neee *f f*\\\ng\n"
+
+	actual := Format(input, &Renderer{Wrap: 10})
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+
+	// 表は折り返さない
+	input = "| aaa bbb | ccc ddd |\n| - | - |\n"
+	expected = "| aaa bbb | ccc ddd |\n| ------- | ------- |\n"
+
+	actual = Format(input, &Renderer{Wrap: 10})
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+}
+
+// 整形済みの文書を整形しても変化しない
+func TestFormatIdempotent(t *testing.T) {
+	inputs := []string{
+		"# Godown - The Markdown Parser in Go -\n\nSorry, this parser is English only\n\n## Markdown Spec\n- Heading\n- *em*\n- ~~Strike through~~\n- `print()`\n",
+		"*Do* *Not* *Use*\n**Do** ***Not***\nThis `Parser` makes `AST`.\n\n---\n\n**Block**\n- Heading\n",
+		"```go\nfunc main() {\n    fmt.Printf(\"`ignore``\")\n}\n```\n\n```rust\nfn main() {}\n```\n",
+		"---\ntitle: \" padded \"\n---\ntext\n",
+		"a[^1] [^2]\n\n[^1]:\n    ```\n    code\n    ```\n\n[^2]: one\n\n    two\n\n[^unused]: x\n",
+		"    code\n    ```\n    more\n\n```\nfenced\n```\n",
+		"soft\nbreak  \nhard\\\nbreak\n- item\n  lazy  \n  line\n\ntwo\nline\n===\n",
+		"- a\n  - b\n\n    c\n\n  ```go\n  x\n\n  y\n  ```\n- d\n",
+		"| a | b |\n|---|:-:|\n| `c` | *d* e |\n\n- | x |\n  |-|\n",
+	}
+
+	for _, input := range inputs {
+		once := Format(input, &Renderer{})
+		twice := Format(once, &Renderer{})
+		if once != twice {
+			t.Errorf("input=%q not idempotent. once=%q, twice=%q", input, once, twice)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package markdown
+
+import (
+	"godown/eastasian"
+	"regexp"
+	"strings"
+)
+
+// 表の列の揃え方
+type alignment int
+
+const (
+	alignNone alignment = iota
+	alignLeft
+	alignRight
+	alignCenter
+)
+
+// 表の区切りの行のセル(---、:--、--:、:-:)
+var delimiterCell = regexp.MustCompile(`^:?-+:?$`)
+
+// パラグラフが表(GFMの| a | b |の行と、その下の| --- | --- |の区切りの行)であれば、列の幅を揃えた表を返す
+// パーサは表を構文解析しないため、パラグラフの各行をセルに分けて整形する
+// 列の幅は全角文字を2として数え、見出しより多い行のセルは揃えずに行の最後に残す
+func formatTable(text string) (string, bool) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) < 2 || !strings.Contains(lines[0]+lines[1], "|") {
+		return "", false
+	}
+
+	header := splitRow(lines[0])
+	delimiters := splitRow(lines[1])
+	if len(header) != len(delimiters) {
+		return "", false
+	}
+
+	aligns := make([]alignment, len(delimiters))
+	for i, cell := range delimiters {
+		if !delimiterCell.MatchString(cell) {
+			return "", false
+		}
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[i] = alignCenter
+		case left:
+			aligns[i] = alignLeft
+		case right:
+			aligns[i] = alignRight
+		}
+	}
+
+	rows := [][]string{header}
+	for _, line := range lines[2:] {
+		row := splitRow(line)
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		rows = append(rows, row)
+	}
+
+	// 区切りの行が:-:のように3文字になるため、列の幅は3以上にする
+	widths := make([]int, len(header))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i := range widths {
+			if n := eastasian.Width(row[i]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var out strings.Builder
+	for i, row := range rows {
+		if i == 1 {
+			writeDelimiterRow(&out, aligns, widths)
+		}
+
+		out.WriteString("|")
+		for j, cell := range row {
+			if j >= len(widths) {
+				out.WriteString(" " + cell + " |")
+				continue
+			}
+			out.WriteString(" " + pad(cell, widths[j], aligns[j]) + " |")
+		}
+		out.WriteString("\n")
+	}
+	if len(rows) == 1 {
+		writeDelimiterRow(&out, aligns, widths)
+	}
+
+	return strings.TrimSuffix(out.String(), "\n"), true
+}
+
+// 区切りの行を出力する
+func writeDelimiterRow(out *strings.Builder, aligns []alignment, widths []int) {
+	out.WriteString("|")
+	for i, width := range widths {
+		cell := strings.Repeat("-", width)
+		switch aligns[i] {
+		case alignLeft:
+			cell = ":" + cell[1:]
+		case alignRight:
+			cell = cell[1:] + ":"
+		case alignCenter:
+			cell = ":" + cell[2:] + ":"
+		}
+		out.WriteString(" " + cell + " |")
+	}
+	out.WriteString("\n")
+}
+
+// 行をセルに分ける
+// 前後の|は取り除き、エスケープした\|ではセルを分けない
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(cells, strings.TrimSpace(line[start:]))
+}
+
+// セルを列の幅まで空白で埋める
+func pad(cell string, width int, align alignment) string {
+	n := width - eastasian.Width(cell)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", n) + cell
+	case alignCenter:
+		return strings.Repeat(" ", n/2) + cell + strings.Repeat(" ", n-n/2)
+	}
+	return cell + strings.Repeat(" ", n)
+}
@@ -14,6 +14,7 @@ const (
 	CODEBLOCK_OBJ      = "CODEBLOCK"
 	PARAGRAPH_OBJ      = "PARAGRAPH"
 	HORIZONTALRULE_OBJ = "HORIZONTAL"
+	FOOTNOTES_OBJ      = "FOOTNOTES"
 )
 
 type Object interface {
@@ -23,7 +24,9 @@ type Object interface {
 
 // 文書全体のオブジェクト
 type Document struct {
-	Objects []Object
+	Objects    []Object
+	Theme      string // CSSテーマのファイルパス(空の場合は既定のテーマ)
+	CopyButton bool   // コードブロックにコピーボタンを付けるスクリプトを埋め込む
 }
 
 func (d *Document) Type() ObjectType { return DOCUMENT_OBJ }
@@ -36,7 +39,7 @@ func (d *Document) Inspect() string {
 
 	return out.String()
 }
-func (d *Document) Render() string {
+func (d *Document) Render() (string, error) {
 	var out bytes.Buffer
 
 	out.WriteString("<!DOCTYPE html>")
@@ -48,7 +51,9 @@ func (d *Document) Render() string {
 	out.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">")
 	out.WriteString("\n")
 
-	style(&out)
+	if err := style(&out, d.Theme); err != nil {
+		return "", err
+	}
 	out.WriteString("\n")
 
 	out.WriteString("</head>")
@@ -59,20 +64,49 @@ func (d *Document) Render() string {
 
 	out.WriteString(d.Inspect())
 
+	if d.CopyButton {
+		out.WriteString(copyScript)
+	}
+
 	out.WriteString("</body>")
 	out.WriteString("\n")
 	out.WriteString("</html>")
 	out.WriteString("\n")
 
-	return out.String()
+	return out.String(), nil
 }
 
-func style(out *bytes.Buffer) {
+// コードブロックにコピーボタンを追加するスクリプト
+// 行番号はコピーしない
+const copyScript = `<script>
+document.querySelectorAll("pre > code").forEach(function (code) {
+  var button = document.createElement("button");
+  button.type = "button";
+  button.className = "copy";
+  button.textContent = "Copy";
+  button.addEventListener("click", function () {
+    var clone = code.cloneNode(true);
+    clone.querySelectorAll(".ln").forEach(function (ln) { ln.remove(); });
+    navigator.clipboard.writeText(clone.textContent.replace(/^\n/, "")).then(function () {
+      button.textContent = "Copied";
+      setTimeout(function () { button.textContent = "Copy"; }, 1500);
+    });
+  });
+  code.parentNode.insertBefore(button, code);
+});
+</script>
+`
+
+func style(out *bytes.Buffer, theme string) error {
 	out.WriteString("<style>\n")
 
-	decorator.Deco(out)
+	if err := decorator.Deco(out, theme); err != nil {
+		return err
+	}
 
 	out.WriteString("</style>\n")
+
+	return nil
 }
 
 // 見出しを表現するオブジェクト
@@ -112,3 +146,11 @@ type HorizontalRule struct{}
 
 func (h *HorizontalRule) Type() ObjectType { return HORIZONTALRULE_OBJ }
 func (h *HorizontalRule) Inspect() string  { return "<hr/>\n" }
+
+// 文書の最後の脚注の一覧
+type Footnotes struct {
+	Value string
+}
+
+func (f *Footnotes) Type() ObjectType { return FOOTNOTES_OBJ }
+func (f *Footnotes) Inspect() string  { return f.Value }
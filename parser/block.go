@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"godown/token"
+	"strings"
+)
+
+// 現在の行を、現在のトークンから改行の手前まで読み込む(トークンは進めない)
+func (p *Parser) curLine() string {
+	saved := p.save()
+	defer p.restore(saved)
+
+	return p.readLine()
+}
+
+// 次の行を読み込む(トークンは進めない)
+// 現在のトークンが改行でない場合は空文字列を返す
+func (p *Parser) peekLine() string {
+	if !p.curTokenIs(token.CR) {
+		return ""
+	}
+
+	saved := p.save()
+	defer p.restore(saved)
+
+	p.nextToken()
+	return p.readLine()
+}
+
+// 現在の行の残りを読み飛ばす(改行は読み飛ばさない)
+func (p *Parser) skipLine() {
+	for !p.curTokenIs(token.CR) && !p.curTokenIs(token.EOF) {
+		p.nextToken()
+	}
+}
+
+// 行頭の空白とタブの幅
+// タブは次の4の倍数の位置まで進める
+func indentation(line string) int {
+	width := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// 行頭の字下げを幅nまで取り除く
+// タブが幅nをまたぐ場合は、超えた分を空白にする
+func trimColumns(line string, n int) string {
+	width := 0
+	for i := 0; i < len(line); i++ {
+		if width >= n {
+			return line[i:]
+		}
+
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+			if width > n {
+				return strings.Repeat(" ", width-n) + line[i+1:]
+			}
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+// 字下げによるコードブロックの行かどうか
+func isIndentedCode(line string) bool {
+	return !isBlankLine(line) && indentation(line) >= 4
+}
+
+// 行頭の3つまでの空白を取り除く
+// 4つ以上の空白で字下げされている場合はokがfalseになる
+func trimIndent(line string) (string, bool) {
+	for i := 0; i < 4; i++ {
+		if i == len(line) || line[i] != ' ' {
+			return line[i:], true
+		}
+	}
+	return line, false
+}
+
+// 空白だけの行かどうか
+func isBlankLine(line string) bool {
+	return strings.TrimLeft(line, " \t") == ""
+}
+
+// ATX形式の見出し(# text)のレベル
+// 1から6個の#の後に空白か行末が続かない場合は0
+func atxHeadingLevel(line string) int {
+	line, ok := trimIndent(line)
+	if !ok {
+		return 0
+	}
+
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0
+	}
+
+	return level
+}
+
+// Setext形式の見出しの下線(===か---)のレベル
+// 下線でない場合は0
+func setextLevel(line string) int {
+	line, ok := trimIndent(line)
+	if !ok {
+		return 0
+	}
+
+	line = strings.TrimRight(line, " \t")
+	switch {
+	case line == "":
+		return 0
+	case strings.Trim(line, "=") == "":
+		return 1
+	case strings.Trim(line, "-") == "":
+		return 2
+	}
+
+	return 0
+}
+
+// 水平線(3つ以上の-、*、_だけの行)かどうか
+// 記号の間と前後には空白とタブを置いてもよい
+func isThematicBreak(line string) bool {
+	line, ok := trimIndent(line)
+	if !ok || line == "" {
+		return false
+	}
+
+	marker := line[0]
+	if marker != '-' && marker != '*' && marker != '_' {
+		return false
+	}
+
+	count := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case marker:
+			count++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+
+	return count >= 3
+}
+
+// リストの項目(- text)かどうか
+// - - -のような水平線は項目ではない
+func isListItem(line string) bool {
+	line, ok := trimIndent(line)
+	if !ok || !strings.HasPrefix(line, "-") || isThematicBreak(line) {
+		return false
+	}
+
+	return len(line) == 1 || line[1] == ' ' || line[1] == '\t'
+}
+
+// リストの項目の内容が始まる位置(項目の続きに必要な字下げの幅)
+// -の後の空白が5つ以上の場合と、内容のない項目は-の後の1つの空白までとする
+func listItemWidth(line string) int {
+	indent := indentation(line)
+	rest := strings.TrimLeft(line, " \t")[1:]
+
+	spaces := indentation(rest)
+	if spaces == 0 || spaces > 4 || isBlankLine(rest) {
+		spaces = 1
+	}
+
+	return indent + 1 + spaces
+}
+
+// 行がパラグラフの続きではなく、新しいブロック要素を始めるかどうか
+func startsBlock(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return atxHeadingLevel(line) > 0 ||
+		isThematicBreak(line) ||
+		(isListItem(line) && !isBlankLine(trimmed[1:])) ||
+		strings.HasPrefix(trimmed, "```")
+}
@@ -0,0 +1,262 @@
+package parser
+
+import (
+	"godown/ast"
+	"godown/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 強調の区切り文字の並び(*か_の連続)
+type delimiter struct {
+	char     byte // *か_
+	length   int  // 元の並びの長さ
+	canOpen  bool // 強調を開始できる
+	canClose bool // 強調を終了できる
+}
+
+// 強調の区切り文字の並びの構文解析
+// 並びはテキストとして返し、前後の文字から強調を開始・終了できるかを記録する
+// beforeは並びの直前の文字(行頭では改行)
+func (p *Parser) parseDelimiterRun(before rune) *ast.Text {
+	tok := p.curToken
+
+	var run strings.Builder
+	for p.curTokenIs(tok.Type) {
+		run.WriteString(p.curToken.Literal)
+		p.nextToken()
+	}
+
+	// 行末と入力の最後は空白とみなす
+	after := '\n'
+	if !p.curTokenIs(token.CR) && !p.curTokenIs(token.EOF) {
+		after, _ = utf8.DecodeRuneInString(p.curToken.Literal)
+	}
+
+	text := &ast.Text{Token: tok, Content: run.String()}
+	text.Token.Literal = text.Content
+
+	d := &delimiter{char: text.Content[0], length: len(text.Content)}
+	d.canOpen, d.canClose = Flanking(d.char, before, after)
+
+	if p.delimiters == nil {
+		p.delimiters = map[*ast.Text]*delimiter{}
+	}
+	p.delimiters[text] = d
+
+	return text
+}
+
+// 区切り文字charの並びが強調を開始・終了できるかどうか
+// beforeとafterは並びの前後の文字で、行頭と行末は改行とする
+func Flanking(char byte, before, after rune) (canOpen, canClose bool) {
+	left := isLeftFlanking(before, after)
+	right := isLeftFlanking(after, before)
+	if char == '*' {
+		return left, right
+	}
+
+	// _は単語の途中では強調にならない
+	return left && (!right || isPunctuation(before)), right && (!left || isPunctuation(after))
+}
+
+// 区切り文字の並びが左側フランキング(強調の開始側)かどうか
+// 引数を入れ替えると右側フランキング(強調の終了側)の判定になる
+func isLeftFlanking(before, after rune) bool {
+	if unicode.IsSpace(after) {
+		return false
+	}
+	return !isPunctuation(after) || unicode.IsSpace(before) || isPunctuation(before)
+}
+
+// 句読点と記号
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// インライン要素の列の最後の文字(列が空の場合は改行)
+func lastRune(inlines []ast.Inline) rune {
+	if len(inlines) == 0 {
+		return '\n'
+	}
+
+	switch inline := inlines[len(inlines)-1].(type) {
+	case *ast.Text:
+		r, _ := utf8.DecodeLastRuneInString(inline.Content)
+		return r
+	case *ast.InlineCode:
+		return '`'
+	case *ast.Strikethrough:
+		return '~'
+	}
+
+	return '\n'
+}
+
+// 強調の処理に使う、インライン要素の双方向リスト
+type inlineNode struct {
+	inline     ast.Inline
+	prev, next *inlineNode
+}
+
+func (n *inlineNode) remove() {
+	n.prev.next = n.next
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+}
+
+// 区切り文字のスタックの要素
+type delimiterRun struct {
+	*delimiter
+	text       *ast.Text
+	node       *inlineNode
+	count      int // 強調に使われずに残っている区切り文字の数
+	index      int // スタックに積んだ順番(底は0)
+	prev, next *delimiterRun
+}
+
+func (r *delimiterRun) remove() {
+	r.prev.next = r.next
+	if r.next != nil {
+		r.next.prev = r.prev
+	}
+}
+
+// 開始側の並びを探す範囲の下限のキー
+// 終了側の並びの文字、開始もできるかどうか、長さを3で割った余りが同じなら、探した結果も同じになる
+type openersBottomKey struct {
+	char    byte
+	canOpen bool
+	mod     int
+}
+
+// 区切り文字の並びを対応させて強調にする(CommonMarkの区切り文字スタックのアルゴリズム)
+// 終了できる並びを先頭から順に見て、その手前で最も近い同じ文字の開始できる並びと対応させる
+// 対応しなかった区切り文字はテキストのまま残る
+// 一度見つからなかった範囲は同じ種類の終了側の並びでは探し直さない(openers_bottom)
+func (p *Parser) processEmphasis(contents []ast.Inline) []ast.Inline {
+	head := &inlineNode{}
+	bottom := &delimiterRun{delimiter: &delimiter{}}
+
+	tail, top := head, bottom
+	for _, inline := range contents {
+		node := &inlineNode{inline: inline, prev: tail}
+		tail.next = node
+		tail = node
+
+		text, ok := inline.(*ast.Text)
+		if !ok {
+			continue
+		}
+		if d, ok := p.delimiters[text]; ok {
+			run := &delimiterRun{delimiter: d, text: text, node: node, count: len(text.Content), index: top.index + 1, prev: top}
+			top.next = run
+			top = run
+		}
+	}
+	if bottom.next == nil {
+		return contents
+	}
+
+	openersBottom := map[openersBottomKey]int{}
+
+	closer := bottom.next
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+
+		key := openersBottomKey{char: closer.char, canOpen: closer.canOpen, mod: closer.length % 3}
+		opener := closer.prev
+		for opener.index > openersBottom[key] && !matches(opener, closer) {
+			opener = opener.prev
+		}
+
+		if opener.index <= openersBottom[key] {
+			openersBottom[key] = closer.prev.index
+			next := closer.next
+			if !closer.canOpen {
+				closer.remove()
+			}
+			closer = next
+			continue
+		}
+
+		// 両方に2つ以上残っていれば強い強調、そうでなければ強調
+		level := 1
+		if opener.count >= 2 && closer.count >= 2 {
+			level = 2
+		}
+
+		var inner []ast.Inline
+		for node := opener.node.next; node != closer.node; node = node.next {
+			inner = append(inner, node.inline)
+		}
+
+		// 強調の区切り文字は、開始側の並びの後ろと終了側の並びの前から使う
+		opener.count -= level
+		marker := opener.text.Content[opener.count:]
+		tok := shift(opener.text.Token, opener.count, marker)
+		node := &inlineNode{inline: newEmphasis(tok, level, inner), prev: opener.node, next: closer.node}
+		opener.node.next = node
+		closer.node.prev = node
+
+		// 間にある区切り文字はもう強調にならない
+		opener.next = closer
+		closer.prev = opener
+
+		opener.text.Content = opener.text.Content[:opener.count]
+		opener.text.Token.Literal = opener.text.Content
+		closer.count -= level
+		closer.text.Content = closer.text.Content[level:]
+		closer.text.Token = shift(closer.text.Token, level, closer.text.Content)
+
+		if opener.count == 0 {
+			opener.node.remove()
+			opener.remove()
+		}
+		if closer.count == 0 {
+			next := closer.next
+			closer.node.remove()
+			closer.remove()
+			closer = next
+		}
+	}
+
+	var out []ast.Inline
+	for node := head.next; node != nil; node = node.next {
+		out = append(out, node.inline)
+	}
+
+	return out
+}
+
+// 開始側と終了側の並びが対応するかどうか
+// 「3の倍数の規則」: どちらかが開始と終了の両方になれる場合、長さの和が3の倍数なら対応させない
+// ただし両方の長さが3の倍数の場合は対応させる
+func matches(opener, closer *delimiterRun) bool {
+	if !opener.canOpen || opener.char != closer.char {
+		return false
+	}
+	if (opener.canClose || closer.canOpen) && (opener.length+closer.length)%3 == 0 {
+		return opener.length%3 == 0 && closer.length%3 == 0
+	}
+	return true
+}
+
+// 強調を生成する
+// 強調の中に強い強調だけがある場合(***text***)は、レベル3の強調にまとめる
+// 強い強調の中の強調(**_text_**)は入れ子のまま残す
+func newEmphasis(tok token.Token, level int, contents []ast.Inline) *ast.Emphasis {
+	if len(contents) == 1 {
+		if inner, ok := contents[0].(*ast.Emphasis); ok && level == 1 && inner.Level == 2 {
+			tok.Literal += inner.Token.Literal
+			return &ast.Emphasis{Token: tok, Level: 3, Contents: inner.Contents}
+		}
+	}
+
+	return &ast.Emphasis{Token: tok, Level: level, Contents: contents}
+}
@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"godown/ast"
+	"godown/lexer"
+	"godown/token"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 脚注の定義の1行目
+	footnoteDefinition = regexp.MustCompile(`^\[\^([^\]\s]+)\]:[ ]?(.*)$`)
+	// 脚注の参照
+	footnoteReference = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+)
+
+// 現在のトークンが脚注の定義の始まりかどうか
+func (p *Parser) curTokenIsFootnote() bool {
+	return p.enabled(Footnotes) && p.curTokenIs(token.TEXT) && strings.HasPrefix(p.curToken.Literal, "[^")
+}
+
+// 次の行が脚注の定義かどうか
+func (p *Parser) peekLineIsFootnote() bool {
+	if !p.enabled(Footnotes) || !p.peekTokenIs(token.TEXT) || !strings.HasPrefix(p.peekToken.Literal, "[^") {
+		return false
+	}
+
+	saved := p.save()
+	defer p.restore(saved)
+
+	p.nextToken()
+	return footnoteDefinition.MatchString(p.readLine())
+}
+
+// 脚注の定義の構文解析
+// 2行目以降は、4つの空白かタブで字下げした行と、パラグラフの続きの行を定義に含める
+func (p *Parser) parseFootnote() *ast.Footnote {
+	if !p.curTokenIsFootnote() {
+		return nil
+	}
+
+	saved := p.save()
+	tok := p.curToken
+
+	m := footnoteDefinition.FindStringSubmatch(p.readLine())
+	if m == nil {
+		p.restore(saved)
+		return nil
+	}
+
+	footnote := &ast.Footnote{Token: tok, Label: m[1]}
+
+	lines := []string{m[2]}
+	for !p.curTokenIs(token.EOF) {
+		saved := p.save()
+		line := p.readLine()
+
+		if strings.HasPrefix(line, "    ") {
+			lines = append(lines, line[4:])
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			lines = append(lines, line[1:])
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// 空行の後は字下げした行だけが定義の続きになる
+			lines = append(lines, "")
+			continue
+		}
+		if lines[len(lines)-1] != "" && !startsBlock(line) && !footnoteDefinition.MatchString(line) {
+			lines = append(lines, line)
+			continue
+		}
+
+		p.restore(saved)
+		break
+	}
+
+	// トークンの行番号を保つため、定義より前の行を空行にして構文解析する
+	src := strings.Repeat("\n", tok.Line-1) + strings.Join(lines, "\n") + "\n"
+	sub := NewWithExtensions(lexer.New(src), p.extensions&^(FrontMatter|Footnotes|HeadingIDs))
+	footnote.Blocks = sub.ParseDocument().Blocks
+
+	return footnote
+}
+
+// 脚注の参照に番号を付け、定義を参照された順に並べる
+// 定義のない参照はテキストのまま残し、参照されていない定義は最後に置く
+func resolveFootnotes(document *ast.Document) {
+	r := &footnoteResolver{
+		definitions: map[string]*ast.Footnote{},
+		refs:        map[*ast.Footnote]int{},
+	}
+
+	// ラベルの大文字と小文字は区別せず、同じラベルの定義は最初のものを使う
+	for _, footnote := range document.Footnotes {
+		label := strings.ToLower(footnote.Label)
+		if _, ok := r.definitions[label]; !ok {
+			r.definitions[label] = footnote
+		}
+	}
+
+	for _, block := range document.Blocks {
+		r.block(block)
+	}
+	// 脚注の中から参照された脚注には、それまでの脚注の後の番号を付ける
+	for i := 0; i < len(r.order); i++ {
+		for _, block := range r.order[i].Blocks {
+			r.block(block)
+		}
+	}
+
+	for _, footnote := range document.Footnotes {
+		if footnote.Index == 0 {
+			r.order = append(r.order, footnote)
+		}
+	}
+	document.Footnotes = r.order
+}
+
+type footnoteResolver struct {
+	definitions map[string]*ast.Footnote
+	order       []*ast.Footnote       // 参照された順の脚注
+	refs        map[*ast.Footnote]int // 脚注ごとの参照の数
+}
+
+func (r *footnoteResolver) block(block ast.Block) {
+	switch block := block.(type) {
+	case *ast.Heading:
+		block.Contents = r.inlines(block.Contents)
+	case *ast.DiscList:
+		for i, item := range block.Lists {
+			block.Lists[i] = r.inlines(item)
+			for _, b := range block.ItemBlocks(i) {
+				r.block(b)
+			}
+		}
+	case *ast.Paragraph:
+		block.Contents = r.inlines(block.Contents)
+	}
+}
+
+// インライン要素の列の中の参照を置き換える
+// 参照は複数のテキストにまたがることがあるため、連続するテキストをまとめて探す
+func (r *footnoteResolver) inlines(inlines []ast.Inline) []ast.Inline {
+	var out []ast.Inline
+	var run []*ast.Text
+
+	for _, inline := range inlines {
+		if text, ok := inline.(*ast.Text); ok {
+			run = append(run, text)
+			continue
+		}
+
+		// 番号は文書の順に付けるため、先にそれまでのテキストを処理する
+		out = append(out, r.text(run)...)
+		run = nil
+
+		switch inline := inline.(type) {
+		case *ast.Emphasis:
+			inline.Contents = r.inlines(inline.Contents)
+		case *ast.Strikethrough:
+			inline.Contents = r.inlines(inline.Contents)
+		}
+		out = append(out, inline)
+	}
+
+	return append(out, r.text(run)...)
+}
+
+func (r *footnoteResolver) text(run []*ast.Text) []ast.Inline {
+	var src strings.Builder
+	for _, t := range run {
+		src.WriteString(t.Content)
+	}
+	s := src.String()
+
+	// 定義のある参照の位置
+	type span struct {
+		start, end int
+		label      string
+		footnote   *ast.Footnote
+	}
+	var spans []span
+	for _, m := range footnoteReference.FindAllStringSubmatchIndex(s, -1) {
+		label := s[m[2]:m[3]]
+		if footnote, ok := r.definitions[strings.ToLower(label)]; ok {
+			spans = append(spans, span{start: m[0], end: m[1], label: label, footnote: footnote})
+		}
+	}
+
+	var out []ast.Inline
+	if len(spans) == 0 {
+		for _, t := range run {
+			out = append(out, t)
+		}
+		return out
+	}
+
+	offset := 0
+	for _, t := range run {
+		start, end := offset, offset+len(t.Content)
+		offset = end
+
+		for pos := start; pos < end; {
+			if len(spans) > 0 && spans[0].start <= pos {
+				if spans[0].start == pos {
+					tok := shift(t.Token, pos-start, s[spans[0].start:spans[0].end])
+					out = append(out, r.reference(spans[0].footnote, spans[0].label, tok))
+				}
+				if spans[0].end <= end {
+					pos = spans[0].end
+					spans = spans[1:]
+				} else {
+					pos = end
+				}
+				continue
+			}
+
+			next := end
+			if len(spans) > 0 && spans[0].start < end {
+				next = spans[0].start
+			}
+			if pos == start && next == end {
+				out = append(out, t)
+			} else {
+				out = append(out, &ast.Text{Token: shift(t.Token, pos-start, s[pos:next]), Content: s[pos:next]})
+			}
+			pos = next
+		}
+	}
+
+	return out
+}
+
+func (r *footnoteResolver) reference(footnote *ast.Footnote, label string, tok token.Token) *ast.FootnoteReference {
+	if footnote.Index == 0 {
+		r.order = append(r.order, footnote)
+		footnote.Index = len(r.order)
+	}
+	r.refs[footnote]++
+
+	return &ast.FootnoteReference{Token: tok, Label: label, Index: footnote.Index, Ref: r.refs[footnote]}
+}
+
+// トークンの位置をn文字後ろにずらし、リテラルを置き換える
+func shift(tok token.Token, n int, literal string) token.Token {
+	tok.Literal = literal
+	if tok.Line > 0 {
+		tok.Column += n
+	}
+	return tok
+}
@@ -4,18 +4,46 @@ import (
 	"godown/ast"
 	"godown/lexer"
 	"godown/token"
+	"strconv"
+	"strings"
+)
+
+// パーサで有効にする拡張機能
+type Extensions uint
+
+const (
+	Strikethrough  Extensions = 1 << iota // ~~打ち消し~~
+	FrontMatter                           // 文書の先頭の---で囲まれたメタデータ
+	HeadingIDs                            // 見出しにid属性を付ける
+	Footnotes                             // [^label]の脚注
+	HardLineBreaks                        // パラグラフの途中の改行をすべて強制改行にする
+
+	NoExtensions Extensions = 0
+	// 既定で有効な拡張機能
+	// FrontMatterは既存の文書のHTML出力を変えないように、必要な出力形式でだけ有効にする
+	CommonExtensions = Strikethrough | Footnotes
 )
 
 // パーサ
 type Parser struct {
 	l *lexer.Lexer
 
+	extensions Extensions
+
 	curToken  token.Token
 	peekToken token.Token
+
+	delimiters map[*ast.Text]*delimiter // 強調の区切り文字の並び
 }
 
+// 既定の拡張機能でパーサを生成
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l}
+	return NewWithExtensions(l, CommonExtensions)
+}
+
+// 拡張機能を指定してパーサを生成
+func NewWithExtensions(l *lexer.Lexer, extensions Extensions) *Parser {
+	p := &Parser{l: l, extensions: extensions}
 
 	p.nextToken()
 	p.nextToken()
@@ -29,47 +57,38 @@ func (p *Parser) nextToken() {
 	p.peekToken = p.l.NextToken()
 }
 
-// 現在のトークンが引数tと等しいか判定する
-func (p *Parser) curTokenIs(t token.TokenType) bool {
-	return p.curToken.Type == t
+// 先読みを取り消すために保存するパーサの状態
+type parserState struct {
+	l         lexer.Lexer
+	curToken  token.Token
+	peekToken token.Token
 }
 
-// 次のトークンが引数tと等しいか判定する
-func (p *Parser) peekTokenIs(t token.TokenType) bool {
-	return p.peekToken.Type == t
+// 現在の状態を保存する
+func (p *Parser) save() parserState {
+	return parserState{l: *p.l, curToken: p.curToken, peekToken: p.peekToken}
 }
 
-// 現在のトークンがブロック要素かどうか
-func (p *Parser) curTokenIsBlockNode() bool {
-	return p.curTokenIs(token.IGETA) || p.curTokenIs(token.HYPHEN)
+// 保存した状態に戻す
+func (p *Parser) restore(s parserState) {
+	*p.l = s.l
+	p.curToken = s.curToken
+	p.peekToken = s.peekToken
 }
 
-// 現在のトークンがインライン要素かどうか
-func (p *Parser) curTokenIsInlineNode(context ast.Block) bool {
-
-	isInlineNode := p.curTokenIs(token.ASTERISK) ||
-		p.curTokenIs(token.BACKQUOTE) ||
-		p.curTokenIs(token.TILDE) ||
-		p.curTokenIs(token.TEXT) ||
-		p.curTokenIs(token.SPACE)
-
-	switch context.(type) {
-	case *ast.Heading:
-		// ヘッダーの文脈では、ハイフンもインラインテキストとして扱う
-		return isInlineNode || p.curTokenIs(token.HYPHEN)
-	}
+// 拡張機能が有効かどうか
+func (p *Parser) enabled(ext Extensions) bool {
+	return p.extensions&ext != 0
+}
 
-	return isInlineNode
+// 現在のトークンが引数tと等しいか判定する
+func (p *Parser) curTokenIs(t token.TokenType) bool {
+	return p.curToken.Type == t
 }
 
-// peekTokenがMarkdownのキーワードかどうか
-func (p *Parser) peekTokenIsKeywords() bool {
-	return p.peekTokenIs(token.IGETA) ||
-		p.peekTokenIs(token.HYPHEN) ||
-		p.peekTokenIs(token.ASTERISK) ||
-		p.peekTokenIs(token.BACKQUOTE) ||
-		p.curTokenIs(token.TILDE) ||
-		p.peekTokenIs(token.TEXT)
+// 次のトークンが引数tと等しいか判定する
+func (p *Parser) peekTokenIs(t token.TokenType) bool {
+	return p.peekToken.Type == t
 }
 
 // アサーション関数
@@ -89,7 +108,16 @@ func (p *Parser) ParseDocument() *ast.Document {
 	document := &ast.Document{}
 	document.Blocks = []ast.Block{}
 
+	if p.enabled(FrontMatter) {
+		document.FrontMatter = p.parseFrontMatter()
+	}
+
 	for !p.curTokenIs(token.EOF) {
+		if footnote := p.parseFootnote(); footnote != nil {
+			document.Footnotes = append(document.Footnotes, footnote)
+			continue
+		}
+
 		block := p.parseDocument()
 		if block != nil {
 			document.Blocks = append(document.Blocks, block)
@@ -100,25 +128,147 @@ func (p *Parser) ParseDocument() *ast.Document {
 		}
 	}
 
+	if p.enabled(Footnotes) {
+		resolveFootnotes(document)
+	}
+
+	if p.enabled(HeadingIDs) {
+		assignHeadingIDs(document)
+	}
+
 	return document
 }
 
-// トークンに応じてそれぞれの関数でパースする
+// 改行か入力の最後までのトークンを元の文字列として読み込む
+func (p *Parser) readLine() string {
+	var out strings.Builder
+
+	for !p.curTokenIs(token.CR) && !p.curTokenIs(token.EOF) {
+		out.WriteString(p.curToken.Literal)
+		p.nextToken()
+	}
+	if p.curTokenIs(token.CR) {
+		p.nextToken()
+	}
+
+	return out.String()
+}
+
+// フロントマターの構文解析
+// 閉じる---がない場合と、空行以外にkey: valueでない行がある場合はフロントマターとみなさない
+func (p *Parser) parseFrontMatter() map[string]string {
+	saved := p.save()
+
+	if strings.TrimRight(p.readLine(), " ") != "---" {
+		p.restore(saved)
+		return nil
+	}
+
+	frontMatter := map[string]string{}
+	for !p.curTokenIs(token.EOF) {
+		line := p.readLine()
+		if strings.TrimRight(line, " ") == "---" {
+			return frontMatter
+		}
+
+		if isBlankLine(line) {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			break
+		}
+
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		frontMatter[key] = value
+	}
+
+	p.restore(saved)
+	return nil
+}
+
+// 見出しに重複しないidを付ける
+func assignHeadingIDs(document *ast.Document) {
+	used := map[string]bool{}
+
+	for _, block := range document.Blocks {
+		heading, ok := block.(*ast.Heading)
+		if !ok {
+			continue
+		}
+
+		base := ast.Slug(ast.PlainText(heading))
+		if base == "" {
+			base = "section"
+		}
+
+		id := base
+		for n := 1; used[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		used[id] = true
+		heading.ID = id
+	}
+}
+
+// 行の種類に応じてそれぞれの関数でパースする
 func (p *Parser) parseDocument() ast.Block {
-	switch p.curToken.Type {
-	case token.IGETA:
+	line := p.curLine()
+
+	switch {
+	case isBlankLine(line):
+		p.skipLine()
+		return nil
+	case isIndentedCode(line):
+		return p.parseIndentedCodeBlock()
+	case atxHeadingLevel(line) > 0:
 		return p.parseHeading()
-	case token.HYPHEN:
+	case isThematicBreak(line):
+		// - - -や* * *はリストや強調ではなく水平線
+		return p.parseHorizontalRule()
+	case isListItem(line):
 		return p.parseDiscList()
-	case token.CR:
-		return nil
 	default:
 		return p.parseParagraph()
 	}
 }
 
-// 見出しの構文解析
+// 空白とタブを読み飛ばす
+func (p *Parser) skipSpaces() {
+	for p.curTokenIs(token.SPACE) || p.curTokenIs(token.TAB) {
+		p.nextToken()
+	}
+}
+
+// 行頭の字下げを幅nまで読み飛ばす
+// タブは次の4の倍数の位置まで進める
+func (p *Parser) skipIndent(n int) {
+	width := 0
+	for width < n {
+		switch {
+		case p.curTokenIs(token.SPACE):
+			width++
+		case p.curTokenIs(token.TAB):
+			width += 4 - width%4
+		default:
+			return
+		}
+		p.nextToken()
+	}
+}
+
+// ATX形式の見出しの構文解析
+// 行頭の3つまでの空白と、末尾の閉じる#の並びは見出しに含めない
 func (p *Parser) parseHeading() *ast.Heading {
+	p.skipSpaces()
+
 	block := &ast.Heading{Token: p.curToken}
 
 	level := 1
@@ -126,113 +276,243 @@ func (p *Parser) parseHeading() *ast.Heading {
 		level++
 	}
 
-	if !p.expectPeek(token.SPACE) {
-		return nil
-	}
-
 	p.nextToken()
+	p.skipSpaces()
 
 	block.Level = level
-	inlineContent := p.parseInlineContent(block)
-	block.Contents = inlineContent
+	block.Contents = p.processEmphasis(trimClosingSequence(p.parseInlineContent()))
 
-	if p.curTokenIs(token.IGETA) || p.curTokenIs(token.HYPHEN) {
-		return block
+	return block
+}
+
+// 見出しの末尾の空白と閉じる#の並びを取り除く
+// #の並びは空白の後にある場合だけ閉じる並びとみなす(# C#の#は残す)
+func trimClosingSequence(contents []ast.Inline) []ast.Inline {
+	contents = trimTrailingSpace(contents)
+
+	end := len(contents)
+	for end > 0 && isTokenText(contents[end-1], token.IGETA) {
+		end--
+	}
+	if end == len(contents) || (end > 0 && !isSpaceText(contents[end-1])) {
+		return contents
 	}
 
-	return block
+	return trimTrailingSpace(contents[:end])
 }
 
-// DISCリストの構文解析
-func (p *Parser) parseDiscList() ast.Block {
-	if p.curTokenIs(token.HYPHEN) && p.peekTokenIs(token.HYPHEN) {
-		// --の場合は水平線としてパース
-		return p.parseHorizontalRule()
+// 末尾の空白とタブを取り除く
+func trimTrailingSpace(contents []ast.Inline) []ast.Inline {
+	for len(contents) > 0 && isSpaceText(contents[len(contents)-1]) {
+		contents = contents[:len(contents)-1]
 	}
+	return contents
+}
+
+// インライン要素が空白かタブのテキストかどうか
+func isSpaceText(inline ast.Inline) bool {
+	return isTokenText(inline, token.SPACE) || isTokenText(inline, token.TAB)
+}
 
-	DiscList := &ast.DiscList{}
+// インライン要素が種類tのトークンのテキストかどうか
+func isTokenText(inline ast.Inline, t token.TokenType) bool {
+	text, ok := inline.(*ast.Text)
+	return ok && text.Token.Type == t
+}
+
+// DISCリストの構文解析
+// 字下げした行はコードブロックではなく項目の続きか、入れ子の項目として扱う
+// 入れ子のリストは1つのリストにまとめ、空行でリストは終わる
+func (p *Parser) parseDiscList() ast.Block {
+	width := listItemWidth(p.curLine())
+	p.skipSpaces()
 
-	listtext := p.parseListItem(DiscList)
-	DiscList.Lists = append(DiscList.Lists, listtext)
+	DiscList := &ast.DiscList{Token: p.curToken}
 
-	for p.curTokenIs(token.HYPHEN) && !p.peekTokenIs(token.HYPHEN) {
-		listtext := p.parseListItem(DiscList)
+	for {
+		listtext := p.parseListItem()
 		DiscList.Lists = append(DiscList.Lists, listtext)
+		if blocks := p.parseListItemBlocks(width); blocks != nil {
+			// 続きのない項目のBlocksはnilのままにする
+			for len(DiscList.Blocks) < len(DiscList.Lists)-1 {
+				DiscList.Blocks = append(DiscList.Blocks, nil)
+			}
+			DiscList.Blocks = append(DiscList.Blocks, blocks)
+		}
+
+		if !p.curTokenIs(token.CR) || !isListItem(strings.TrimLeft(p.peekLine(), " \t")) {
+			break
+		}
+		p.nextToken()
+		width = listItemWidth(p.curLine())
 	}
 
 	return DiscList
 }
 
+// 水平線の構文解析
 func (p *Parser) parseHorizontalRule() *ast.HorizontalRule {
+	p.skipSpaces()
 
-	for p.curTokenIs(token.HYPHEN) {
-		p.nextToken()
-	}
+	rule := &ast.HorizontalRule{Token: p.curToken}
+	p.skipLine()
 
-	if p.curTokenIs(token.CR) {
-		p.nextToken()
-	}
-
-	return &ast.HorizontalRule{}
+	return rule
 }
 
 // リストアイテムの構文解析
-func (p *Parser) parseListItem(context ast.Block) []ast.Inline {
+// 次の項目か、空行か、新しいブロック要素までの行を項目の続きとする
+func (p *Parser) parseListItem() []ast.Inline {
+	p.skipSpaces()
 
 	if p.curTokenIs(token.HYPHEN) {
 		p.nextToken()
 	}
-	if p.curTokenIs(token.SPACE) {
-		p.nextToken()
+	p.skipSpaces()
+
+	contents := p.parseInlineContent()
+
+	for p.curTokenIs(token.CR) {
+		next := p.peekLine()
+		if isBlankLine(next) || isListItem(strings.TrimLeft(next, " \t")) || startsBlock(next) || p.peekLineIsFootnote() {
+			break
+		}
+
+		contents = p.parseLineBreak(contents)
+		contents = append(contents, p.parseInlineContent()...)
 	}
 
-	listContent := p.parseInlineContent(context)
+	return p.processEmphasis(trimTrailingSpace(contents))
+}
+
+// リストの項目の続きのブロックの構文解析
+// 項目の内容の位置(width)まで字下げした行を、間の空行も含めて項目の続きとする(入れ子のリストなど)
+// 字下げを取り除いた行は、脚注の定義と同じように別の構文解析器で構文解析する
+func (p *Parser) parseListItemBlocks(width int) []ast.Block {
+	end := p.save()
 
-	if !p.curTokenIs(token.HYPHEN) {
+	var lines []string
+	first, found := 0, false
+	for p.curTokenIs(token.CR) {
 		p.nextToken()
+		if first == 0 {
+			first = p.curToken.Line
+		}
+
+		line := p.curLine()
+		p.skipLine()
+
+		if isBlankLine(line) {
+			lines = append(lines, "")
+			continue
+		}
+		if indentation(line) < width {
+			break
+		}
+
+		lines = append(lines, trimColumns(line, width))
+		end, found = p.save(), true
+	}
+	p.restore(end)
+
+	if !found {
+		return nil
 	}
 
-	return listContent
+	// トークンの行番号を保つため、項目より前の行を空行にして構文解析する
+	src := strings.Repeat("\n", first-1) + strings.Join(lines, "\n") + "\n"
+	sub := NewWithExtensions(lexer.New(src), p.extensions&^(FrontMatter|Footnotes|HeadingIDs))
+	return sub.ParseDocument().Blocks
 }
 
 // パラグラフのパース
+// 下線(===か---)が続く場合はSetext形式の見出しになる
 func (p *Parser) parseParagraph() ast.Block {
+	p.skipSpaces()
+
 	if p.curTokenIs(token.BACKQUOTE) && p.peekTokenIs(token.BACKQUOTE) {
 		// ``の場合はコードブロックとしてパース
 		return p.parseCodeBlock()
 	}
 
-	paragraph := &ast.Paragraph{}
+	paragraph := &ast.Paragraph{Token: p.curToken}
 
-	for !(p.curTokenIs(token.EOF) || p.curTokenIsBlockNode()) {
-		inlineContent := p.parseInlineContent(paragraph)
-		if inlineContent != nil {
-			paragraph.Contents = append(paragraph.Contents, inlineContent...)
+	for {
+		paragraph.Contents = append(paragraph.Contents, p.parseInlineContent()...)
+
+		if !p.curTokenIs(token.CR) {
+			break
 		}
 
-		if p.curTokenIs(token.CR) || p.curTokenIs(token.TEXT) || p.curTokenIs(token.SPACE) {
+		next := p.peekLine()
+		if level := setextLevel(next); level > 0 {
 			p.nextToken()
+			p.skipLine()
+			return &ast.Heading{Token: paragraph.Token, Level: level, Contents: p.processEmphasis(trimTrailingSpace(paragraph.Contents))}
 		}
+
+		// 空行か、新しいブロック要素か、脚注の定義でパラグラフは終わる
+		if isBlankLine(next) || startsBlock(next) || p.peekLineIsFootnote() {
+			break
+		}
+
+		paragraph.Contents = p.parseLineBreak(paragraph.Contents)
 	}
 
+	paragraph.Contents = p.processEmphasis(trimTrailingSpace(paragraph.Contents))
+
 	return paragraph
 }
 
+// 行の途中の改行の構文解析
+// 行末の2つ以上の空白かバックスラッシュは強制改行、それ以外はソフト改行にする
+// 行末の空白と次の行の行頭の空白は取り除き、次の行の先頭までトークンを進める
+func (p *Parser) parseLineBreak(contents []ast.Inline) []ast.Inline {
+	tok := p.curToken
+	hard := p.enabled(HardLineBreaks)
+
+	trimmed := trimTrailingSpace(contents)
+	if len(contents)-len(trimmed) >= 2 {
+		hard = true
+	} else if len(trimmed) == len(contents) && len(trimmed) > 0 {
+		last := trimmed[len(trimmed)-1]
+		if text, ok := last.(*ast.Text); ok && strings.HasSuffix(text.Content, "\\") {
+			hard = true
+			trimmed = trimmed[:len(trimmed)-1]
+			if content := strings.TrimSuffix(text.Content, "\\"); content != "" {
+				text := &ast.Text{Token: text.Token, Content: content}
+				text.Token.Literal = content
+				trimmed = append(trimmed, text)
+			}
+		}
+	}
+
+	p.nextToken()
+	p.skipSpaces()
+
+	if hard {
+		return append(trimmed, &ast.HardBreak{Token: tok})
+	}
+	return append(trimmed, &ast.SoftBreak{Token: tok})
+}
+
 // コードブロックのパース
 func (p *Parser) parseCodeBlock() ast.Block {
-	codeBlock := &ast.CodeBlock{}
+	codeBlock := &ast.CodeBlock{Token: p.curToken}
 
 	for p.curTokenIs(token.BACKQUOTE) {
 		p.nextToken()
 	}
 
-	if !p.curTokenIs(token.CR) {
-		codeBlock.Lang = p.parseInlineText()
-		p.nextToken()
-		p.nextToken()
+	// 情報文字列は言語と属性(```go {linenos=true hl=3-5})
+	tok := p.curToken
+	lang, attributes := parseInfoString(p.readLine())
+	if lang != "" {
+		codeBlock.Lang = &ast.Text{Token: tok, Content: lang}
 	}
+	codeBlock.Attributes = attributes
 
-	for !p.curTokenIs(token.BACKQUOTE) {
+	for !p.curTokenIs(token.BACKQUOTE) && !p.curTokenIs(token.EOF) {
 		codeBlock.Contents = p.parseCodeBlockContent()
 	}
 
@@ -250,7 +530,10 @@ func (p *Parser) parseCodeBlock() ast.Block {
 			break
 		} else {
 			codeBlock.Contents = append(codeBlock.Contents, tmp...)
-			for !p.curTokenIs(token.BACKQUOTE) {
+			if p.curTokenIs(token.EOF) {
+				break
+			}
+			for !p.curTokenIs(token.BACKQUOTE) && !p.curTokenIs(token.EOF) {
 				codeBlock.Contents = append(codeBlock.Contents, p.parseCodeBlockContent()...)
 			}
 		}
@@ -259,10 +542,115 @@ func (p *Parser) parseCodeBlock() ast.Block {
 	return codeBlock
 }
 
+// 字下げによるコードブロックの構文解析
+// 各行の先頭の4桁分の字下げだけを取り除き、残りの空白はそのまま残す
+// 途中の空行はコードに含め、末尾の空行は含めない
+func (p *Parser) parseIndentedCodeBlock() ast.Block {
+	codeBlock := &ast.CodeBlock{Token: p.curToken, Indented: true}
+
+	var blank []ast.Inline
+	for {
+		line := p.curLine()
+
+		p.skipIndent(4)
+		contents := p.parseCodeLine()
+
+		if isBlankLine(line) {
+			blank = append(blank, contents...)
+		} else {
+			codeBlock.Contents = append(codeBlock.Contents, blank...)
+			codeBlock.Contents = append(codeBlock.Contents, contents...)
+			blank = nil
+		}
+
+		if !p.curTokenIs(token.CR) {
+			break
+		}
+		if next := p.peekLine(); !isBlankLine(next) && !isIndentedCode(next) {
+			break
+		}
+		p.nextToken()
+	}
+
+	return codeBlock
+}
+
+// 改行か入力の最後までのトークンを、行末の改行と合わせてテキストにする(改行は読み飛ばさない)
+func (p *Parser) parseCodeLine() []ast.Inline {
+	var contents []ast.Inline
+
+	for !p.curTokenIs(token.CR) && !p.curTokenIs(token.EOF) {
+		contents = append(contents, p.parseInlineText())
+		p.nextToken()
+	}
+
+	tok := p.curToken
+	tok.Type, tok.Literal = token.CR, "\n"
+	return append(contents, &ast.Text{Token: tok, Content: "\n"})
+}
+
+// 情報文字列を言語と属性に分ける
+// 属性はkey=value、key="value"、keyの形式で、全体を{}で囲んでもよい
+// 値のない属性の値は"true"とする
+// 言語と属性は空白かタブで区切る
+func parseInfoString(info string) (string, map[string]string) {
+	info = strings.TrimSpace(info)
+
+	var lang string
+	if !strings.HasPrefix(info, "{") {
+		lang = info
+		if i := strings.IndexAny(info, " \t{"); i >= 0 {
+			lang, info = info[:i], info[i:]
+		} else {
+			info = ""
+		}
+	}
+
+	info = strings.TrimSpace(info)
+	if strings.HasPrefix(info, "{") && strings.HasSuffix(info, "}") {
+		info = info[1 : len(info)-1]
+	}
+
+	var attributes map[string]string
+	for info = strings.TrimSpace(info); info != ""; info = strings.TrimSpace(info) {
+		end := strings.IndexAny(info, " \t=")
+		if end < 0 {
+			end = len(info)
+		}
+		key, value := info[:end], "true"
+		info = info[end:]
+
+		if strings.HasPrefix(info, "=") {
+			info = info[1:]
+			if strings.HasPrefix(info, `"`) {
+				end = strings.Index(info[1:], `"`)
+				if end < 0 {
+					value, info = info[1:], ""
+				} else {
+					value, info = info[1:end+1], info[end+2:]
+				}
+			} else {
+				end = strings.IndexAny(info, " \t")
+				if end < 0 {
+					end = len(info)
+				}
+				value, info = info[:end], info[end:]
+			}
+		}
+
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+		attributes[key] = value
+	}
+
+	return lang, attributes
+}
+
 func (p *Parser) parseCodeBlockContent() []ast.Inline {
 	var contents []ast.Inline
 
-	for !p.curTokenIs(token.BACKQUOTE) {
+	for !p.curTokenIs(token.BACKQUOTE) && !p.curTokenIs(token.EOF) {
 		contents = append(contents, p.parseInlineText())
 		p.nextToken()
 	}
@@ -271,18 +659,25 @@ func (p *Parser) parseCodeBlockContent() []ast.Inline {
 }
 
 // インライン要素の構文解析
-func (p *Parser) parseInlineContent(context ast.Block) []ast.Inline {
+// 改行か入力の最後まで読み込む(改行は読み飛ばさない)
+// *と_の並びはテキストのまま残し、ブロック要素ごとにprocessEmphasisで強調にする
+func (p *Parser) parseInlineContent() []ast.Inline {
 	var inlineContents []ast.Inline
 
-	for p.curTokenIsInlineNode(context) {
+	for !p.curTokenIs(token.CR) && !p.curTokenIs(token.EOF) {
 		var inlineContent ast.Inline
 
 		switch p.curToken.Type {
-		case token.ASTERISK:
-			inlineContent = p.parseInlineEmphasis()
+		case token.ASTERISK, token.UNDERSCORE:
+			inlineContent = p.parseDelimiterRun(lastRune(inlineContents))
 		case token.BACKQUOTE:
 			inlineContent = p.parseInlineCode()
 		case token.TILDE:
+			if !p.enabled(Strikethrough) {
+				inlineContent = p.parseInlineText()
+				p.nextToken()
+				break
+			}
 			inlineContent = p.parseInlineStrikethrough()
 		default:
 			inlineContent = p.parseInlineText()
@@ -292,74 +687,63 @@ func (p *Parser) parseInlineContent(context ast.Block) []ast.Inline {
 		if inlineContent != nil {
 			inlineContents = append(inlineContents, inlineContent)
 		}
-
-		switch context.(type) {
-		case *ast.Heading:
-			// ヘッダーの文脈では、改行が来たらブロック終了
-			if p.curTokenIs(token.CR) {
-				p.nextToken()
-				return inlineContents
-			}
-		}
-
-		if !p.curTokenIsInlineNode(context) {
-			p.nextToken()
-		}
-
-		if p.curTokenIs(token.CR) && p.peekTokenIsKeywords() {
-			return inlineContents
-		}
 	}
 
 	return inlineContents
 }
 
-// 強調の構文解析
-func (p *Parser) parseInlineEmphasis() *ast.Emphasis {
-	emphasis := &ast.Emphasis{Token: p.curToken}
+// 打ち消しの構文解析
+// 同じ行にある同じ長さの~の並びで閉じる
+// 閉じる並びがない場合は、開始側の~の並びをテキストとして返す
+func (p *Parser) parseInlineStrikethrough() ast.Inline {
+	saved := p.save()
+	strikethrough := &ast.Strikethrough{Token: p.curToken}
 
-	level := 1
-	for p.expectPeek(token.ASTERISK) {
-		level++
-	}
+	count := p.skipTildes()
 
-	p.nextToken()
+	for !p.curTokenIs(token.CR) && !p.curTokenIs(token.EOF) {
+		if p.curTokenIs(token.TILDE) {
+			tok := p.curToken
+			if n := p.skipTildes(); n != count {
+				text := &ast.Text{Token: tok, Content: strings.Repeat("~", n)}
+				text.Token.Literal = text.Content
+				strikethrough.Contents = append(strikethrough.Contents, text)
+				continue
+			}
 
-	emphasis.Level = level
+			strikethrough.Contents = p.processEmphasis(strikethrough.Contents)
+			return strikethrough
+		}
 
-	for !p.curTokenIs(token.ASTERISK) {
-		emphasis.Contents = append(emphasis.Contents, p.parseInlineText())
+		if p.curTokenIs(token.ASTERISK) || p.curTokenIs(token.UNDERSCORE) {
+			before := lastRune(strikethrough.Contents)
+			if len(strikethrough.Contents) == 0 {
+				before = '~'
+			}
+			strikethrough.Contents = append(strikethrough.Contents, p.parseDelimiterRun(before))
+			continue
+		}
+		strikethrough.Contents = append(strikethrough.Contents, p.parseInlineText())
 		p.nextToken()
 	}
 
-	for i := 0; i < level; i++ {
-		p.nextToken()
-	}
+	p.restore(saved)
 
-	return emphasis
-}
+	tok := p.curToken
+	text := &ast.Text{Token: tok, Content: strings.Repeat("~", p.skipTildes())}
+	text.Token.Literal = text.Content
 
-// 打ち消しの構文解析
-func (p *Parser) parseInlineStrikethrough() *ast.Strikethrough {
-	strikethrough := &ast.Strikethrough{Token: p.curToken}
-
-	count := 1
-	for p.expectPeek(token.TILDE) {
-		count++
-	}
-
-	p.nextToken()
-
-	for !p.curTokenIs(token.TILDE) {
-		strikethrough.Contents = append(strikethrough.Contents, p.parseInlineText())
-		p.nextToken()
-	}
+	return text
+}
 
-	for i := 0; i < count; i++ {
+// ~の並びを読み飛ばし、その長さを返す
+func (p *Parser) skipTildes() int {
+	count := 0
+	for p.curTokenIs(token.TILDE) {
 		p.nextToken()
+		count++
 	}
-
-	return strikethrough
+	return count
 }
 
 // インラインコードの構文解析
@@ -368,7 +752,7 @@ func (p *Parser) parseInlineCode() *ast.InlineCode {
 
 	p.nextToken()
 
-	for !p.curTokenIs(token.BACKQUOTE) {
+	for !p.curTokenIs(token.BACKQUOTE) && !p.curTokenIs(token.EOF) {
 		inlineCode.Contents = append(inlineCode.Contents, p.parseInlineText())
 		p.nextToken()
 	}
@@ -1,8 +1,12 @@
 package parser
 
 import (
+	"godown/ast"
 	"godown/lexer"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHeading(t *testing.T) {
@@ -32,7 +36,7 @@ func TestHeading(t *testing.T) {
 		},
 		{
 			"# ***-text-***",
-			"<h1><strong><em>-text-</em></strong></h1>\n",
+			"<h1><em><strong>-text-</strong></em></h1>\n",
 		},
 		{
 			"## Heading*2*",
@@ -46,6 +50,34 @@ func TestHeading(t *testing.T) {
 			"## - text *-*",
 			"<h2>- text <em>-</em></h2>\n",
 		},
+		{
+			"   ### text",
+			"<h3>text</h3>\n",
+		},
+		{
+			"# text ##  ",
+			"<h1>text</h1>\n",
+		},
+		{
+			"# C#",
+			"<h1>C#</h1>\n",
+		},
+		{
+			"# text # b",
+			"<h1>text # b</h1>\n",
+		},
+		{
+			"#",
+			"<h1></h1>\n",
+		},
+		{
+			"#text",
+			"<p>#text</p>\n",
+		},
+		{
+			"####### text",
+			"<p>####### text</p>\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -60,6 +92,50 @@ func TestHeading(t *testing.T) {
 	}
 }
 
+// Setext形式の見出しのテスト
+func TestSetextHeading(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"text\n===",
+			"<h1>text</h1>\n",
+		},
+		{
+			"*text*\n  ---  \n",
+			"<h2><em>text</em></h2>\n",
+		},
+		{
+			"a\n-\nb",
+			"<h2>a</h2>\n<p>b</p>\n",
+		},
+		{
+			"a\n- b",
+			"<p>a</p>\n<p>\n<ul>\n<li>b</li>\n</ul>\n</p>\n",
+		},
+		{
+			"a\n\n---",
+			"<p>a</p>\n<hr/>\n",
+		},
+		{
+			"a\n= =",
+			"<p>a\n= =</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		document := p.ParseDocument()
+
+		actual := document.String()
+		if actual != tt.expected {
+			t.Errorf("input=%q wong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
 // ひとまず1行だけしか構文解析できない
 func TestEM(t *testing.T) {
 	tests := []struct {
@@ -76,7 +152,7 @@ func TestEM(t *testing.T) {
 		},
 		{
 			"***te xt***",
-			"<p><strong><em>te xt</em></strong></p>\n",
+			"<p><em><strong>te xt</strong></em></p>\n",
 		},
 		{
 			"text*text*",
@@ -92,16 +168,80 @@ func TestEM(t *testing.T) {
 		},
 		{
 			"*text* ***text***",
-			"<p><em>text</em> <strong><em>text</em></strong></p>\n",
+			"<p><em>text</em> <em><strong>text</strong></em></p>\n",
 		},
 		{
 			"*text****text***",
-			"<p><em>text</em><strong><em>text</em></strong></p>\n",
+			"<p><em>text</em><em><strong>text</strong></em></p>\n",
 		},
 		{
 			"**text**`hoge`*huga*",
 			"<p><strong>text</strong><code>hoge</code><em>huga</em></p>\n",
 		},
+		{
+			"**a *b* c**",
+			"<p><strong>a <em>b</em> c</strong></p>\n",
+		},
+		{
+			"***a** b*",
+			"<p><em><strong>a</strong> b</em></p>\n",
+		},
+		{
+			"_a_ __b__ ___c___",
+			"<p><em>a</em> <strong>b</strong> <em><strong>c</strong></em></p>\n",
+		},
+		{
+			"foo***bar***baz",
+			"<p>foo<em><strong>bar</strong></em>baz</p>\n",
+		},
+		{
+			"**_a_** _**b**_",
+			"<p><strong><em>a</em></strong> <em><strong>b</strong></em></p>\n",
+		},
+		{
+			"*foo**bar*",
+			"<p><em>foo**bar</em></p>\n",
+		},
+		{
+			"snake_case_name",
+			"<p>snake_case_name</p>\n",
+		},
+		{
+			"_foo_bar_baz_",
+			"<p><em>foo_bar_baz</em></p>\n",
+		},
+		{
+			"*a** **a*",
+			"<p><em>a</em>* *<em>a</em></p>\n",
+		},
+		{
+			"*a**b*",
+			"<p><em>a**b</em></p>\n",
+		},
+		{
+			"a * b *",
+			"<p>a * b *</p>\n",
+		},
+		{
+			"a**\"b\"**",
+			"<p>a**\"b\"**</p>\n",
+		},
+		{
+			"*a\nb*",
+			"<p><em>a\nb</em></p>\n",
+		},
+		{
+			"~~a *b*~~",
+			"<p><s>a <em>b</em></s></p>\n",
+		},
+		{
+			"~~a\n\n# b ~~c~~",
+			"<p>~~a</p>\n<h1>b <s>c</s></h1>\n",
+		},
+		{
+			"~~a~b~~ ~c~~",
+			"<p><s>a~b</s> ~c~~</p>\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -116,6 +256,24 @@ func TestEM(t *testing.T) {
 	}
 }
 
+// 対応しない区切り文字が多くても、探し直さずに処理できること
+func TestEMManyDelimiters(t *testing.T) {
+	input := strings.Repeat("*a ", 30000) + strings.Repeat("b_ ", 30000)
+
+	start := time.Now()
+	l := lexer.New(input)
+	p := New(l)
+	document := p.ParseDocument()
+	elapsed := time.Since(start)
+
+	if strings.Contains(document.String(), "<em>") {
+		t.Errorf("unexpected emphasis in output")
+	}
+	if elapsed > time.Second {
+		t.Errorf("took too long. elapsed=%v", elapsed)
+	}
+}
+
 // インラインコードのテスト
 // ひとまず1行だけしか構文解析できない
 func TestInlineCode(t *testing.T) {
@@ -205,6 +363,22 @@ func TestDiscList(t *testing.T) {
 </p>
 `,
 		},
+		{
+			"- a\n\n  b\n- c\n\nd",
+			"<p>\n<ul>\n<li>a\n<p>b</p>\n</li>\n<li>c</li>\n</ul>\n</p>\n<p>d</p>\n",
+		},
+		{
+			"- a\n  - b\n    - c\n  - d\n- e",
+			"<p>\n<ul>\n<li>a\n<p>\n<ul>\n<li>b\n<p>\n<ul>\n<li>c</li>\n</ul>\n</p>\n</li>\n<li>d</li>\n</ul>\n</p>\n</li>\n<li>e</li>\n</ul>\n</p>\n",
+		},
+		{
+			"- a\n\n  ```go\n  x\n  ```\n\n  # h\n",
+			"<p>\n<ul>\n<li>a\n<pre class=\"language-go\">\n<code>\nx\n</code>\n</pre>\n<h1>h</h1>\n</li>\n</ul>\n</p>\n",
+		},
+		{
+			"-   a\n\n    b\n\n  c",
+			"<p>\n<ul>\n<li>a\n<p>b</p>\n</li>\n</ul>\n</p>\n<p>c</p>\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -246,6 +420,50 @@ func TestMultipleDiscList(t *testing.T) {
 	}
 }
 
+// 水平線のテスト
+func TestHorizontalRule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"---\n\n***\n___\n - - -\n *  * *\t\n",
+			"<hr/>\n<hr/>\n<hr/>\n<hr/>\n<hr/>\n",
+		},
+		{
+			"--flag\n",
+			"<p>--flag</p>\n",
+		},
+		{
+			"-- flag\n",
+			"<p>-- flag</p>\n",
+		},
+		{
+			"a\n* * *\nb",
+			"<p>a</p>\n<hr/>\n<p>b</p>\n",
+		},
+		{
+			"- a\n- - -\n- b",
+			"<p>\n<ul>\n<li>a</li>\n</ul>\n</p>\n<hr/>\n<p>\n<ul>\n<li>b</li>\n</ul>\n</p>\n",
+		},
+		{
+			"    ***",
+			"<pre class=\"language-\">\n<code>\n***\n</code>\n</pre>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		document := p.ParseDocument()
+
+		actual := document.String()
+		if actual != tt.expected {
+			t.Errorf("input=%q wong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
 // 複数のトークンの構文解析
 func TestMultipeTokens(t *testing.T) {
 	input := `# Heading
@@ -267,7 +485,7 @@ func TestMultipeTokens(t *testing.T) {
 ## Heading*2*`
 
 	expected := `<h1>Heading</h1>
-<hr>
+<hr/>
 <p>
 <ul>
 <li>a</li>
@@ -275,8 +493,10 @@ func TestMultipeTokens(t *testing.T) {
 <li>c3</li>
 </ul>
 </p>
-<hr>
-<p><strong><em>te3 xt</em></strong><em>1text</em><strong>text2</strong></p>
+<hr/>
+<p><em><strong>te3 xt</strong></em>
+<em>1text</em>
+<strong>text2</strong></p>
 <h2>Heading<em>2</em></h2>
 `
 
@@ -311,11 +531,13 @@ This is a text.
 
 	expected := `<h1><em>text</em>1MIDASHI1</h1>
 <h2>M2</h2>
-<p><em>2text</em><em>text2</em><s>strikethrough</s></p>
+<p><em>2text</em>
+<em>text2</em>
+<s>strikethrough</s></p>
 <h2>Heading<em>2</em> <em>text</em></h2>
 <p>3text 999 hoge</p>
-<hr>
-<h3>h3<strong>!!!</strong></h3>
+<hr/>
+<h3>h3**!!!**</h3>
 <p>This is a text.</p>
 `
 
@@ -356,3 +578,309 @@ func TestCodeBlock(t *testing.T) {
 			input, expected, actual)
 	}
 }
+
+// 字下げによるコードブロックの構文解析
+func TestIndentedCodeBlock(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"    a\n  \tb\n\n      c\n\n\nd",
+			"<pre class=\"language-\">\n<code>\na\nb\n\n  c\n</code>\n</pre>\n<p>d</p>\n",
+		},
+		{
+			"\tif x {\n\t\ty()\n\t}",
+			"<pre class=\"language-\">\n<code>\nif x {\n\ty()\n}\n</code>\n</pre>\n",
+		},
+		{
+			"text\n    more",
+			"<p>text\nmore</p>\n",
+		},
+		{
+			"- a\n    b\n    - c\n\n    code",
+			"<p>\n<ul>\n<li>a\nb\n<p>\n<ul>\n<li>c</li>\n</ul>\n</p>\n<p>code</p>\n</li>\n</ul>\n</p>\n",
+		},
+		{
+			"- a\n\n      <b> & c\n",
+			"<p>\n<ul>\n<li>a\n<pre class=\"language-\">\n<code>\n<b> & c\n</code>\n</pre>\n</li>\n</ul>\n</p>\n",
+		},
+		{
+			"- a\n\n    code",
+			"<p>\n<ul>\n<li>a\n<p>code</p>\n</li>\n</ul>\n</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		document := p.ParseDocument()
+
+		actual := document.String()
+		if actual != tt.expected {
+			t.Errorf("input=%q wong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+
+		if block, ok := document.Blocks[len(document.Blocks)-1].(*ast.CodeBlock); ok && !block.Indented {
+			t.Errorf("input=%q code block is not marked as indented", tt.input)
+		}
+	}
+}
+
+// パラグラフの途中の改行の構文解析
+func TestLineBreaks(t *testing.T) {
+	tests := []struct {
+		input      string
+		extensions Extensions
+		expected   string
+	}{
+		{
+			"a\n  b \nc",
+			CommonExtensions,
+			"<p>a\nb\nc</p>\n",
+		},
+		{
+			"a  \nb\\\n*c*\t\t\nd\\",
+			CommonExtensions,
+			"<p>a<br/>\nb<br/>\n<em>c</em><br/>\nd\\</p>\n",
+		},
+		{
+			"- a   \n  b",
+			CommonExtensions,
+			"<p>\n<ul>\n<li>a<br/>\nb</li>\n</ul>\n</p>\n",
+		},
+		{
+			"a\nb  ",
+			CommonExtensions | HardLineBreaks,
+			"<p>a<br/>\nb</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := NewWithExtensions(l, tt.extensions)
+		document := p.ParseDocument()
+
+		actual := document.String()
+		if actual != tt.expected {
+			t.Errorf("input=%q wong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+// フロントマターの構文解析
+func TestFrontMatter(t *testing.T) {
+	tests := []struct {
+		input       string
+		frontMatter map[string]string
+		expected    string
+	}{
+		{
+			"---\ntitle: \"Hello: World\"\nweight: 2\n---\n# a",
+			map[string]string{"title": "Hello: World", "weight": "2"},
+			"<h1>a</h1>\n",
+		},
+		{
+			"---\n\n# a",
+			nil,
+			"<hr/>\n<h1>a</h1>\n",
+		},
+		{
+			"---\nhello\n---\nbody\n",
+			nil,
+			"<hr/>\n<h2>hello</h2>\n<p>body</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := NewWithExtensions(l, CommonExtensions|FrontMatter)
+		document := p.ParseDocument()
+
+		if !reflect.DeepEqual(document.FrontMatter, tt.frontMatter) {
+			t.Errorf("input=%q front matter wrong. expected=%v, got=%v",
+				tt.input, tt.frontMatter, document.FrontMatter)
+		}
+
+		actual := document.String()
+		if actual != tt.expected {
+			t.Errorf("input=%q wong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+// フロントマターは既定では無効
+func TestFrontMatterDisabled(t *testing.T) {
+	input := "---\ntitle: a\n---\nbody\n"
+	expected := "<hr/>\n<h2>title: a</h2>\n<p>body</p>\n"
+
+	l := lexer.New(input)
+	p := New(l)
+	document := p.ParseDocument()
+
+	if document.FrontMatter != nil {
+		t.Errorf("front matter was parsed. got=%v", document.FrontMatter)
+	}
+	if actual := document.String(); actual != expected {
+		t.Errorf("input=%q wong. expected=%q, got=%q", input, expected, actual)
+	}
+}
+
+// 見出しのidの付与
+func TestHeadingIDs(t *testing.T) {
+	input := "# Hello World\n## Hello *World*\n## a-1\n## a\n## a\n"
+
+	expected := `<h1 id="hello-world">Hello World</h1>
+<h2 id="hello-world-1">Hello <em>World</em></h2>
+<h2 id="a-1">a-1</h2>
+<h2 id="a">a</h2>
+<h2 id="a-2">a</h2>
+`
+
+	l := lexer.New(input)
+	p := NewWithExtensions(l, CommonExtensions|HeadingIDs)
+	document := p.ParseDocument()
+
+	actual := document.String()
+	if actual != expected {
+		t.Errorf("input=%q wong. expected=%q, got=%q", input, expected, actual)
+	}
+}
+
+// 脚注の参照と定義の構文解析
+func TestFootnotes(t *testing.T) {
+	input := "a[^note-1] b*c[^2]*[^x]\n" +
+		"[^2]: two\n" +
+		"[^Note-1]: one\n" +
+		"\n" +
+		"    more\n" +
+		"[^unused]: unused\n" +
+		"\n" +
+		"d[^NOTE-1]\n"
+
+	expected := "<p>a<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\">1</a></sup> b" +
+		"<em>c<sup class=\"footnote-ref\"><a href=\"#fn-2\" id=\"fnref-2\">2</a></sup></em>[^x]</p>\n" +
+		"<p>d<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1-2\">1</a></sup></p>\n"
+
+	l := lexer.New(input)
+	p := New(l)
+	document := p.ParseDocument()
+
+	actual := document.String()
+	if actual != expected {
+		t.Errorf("input=%q wong. expected=%q, got=%q", input, expected, actual)
+	}
+
+	footnotes := []struct {
+		label  string
+		index  int
+		blocks string
+	}{
+		{"Note-1", 1, "<p>one</p>\n<p>more</p>\n"},
+		{"2", 2, "<p>two</p>\n"},
+		{"unused", 0, "<p>unused</p>\n"},
+	}
+
+	if len(document.Footnotes) != len(footnotes) {
+		t.Fatalf("wrong number of footnotes. expected=%d, got=%d", len(footnotes), len(document.Footnotes))
+	}
+	for i, tt := range footnotes {
+		footnote := document.Footnotes[i]
+
+		var blocks strings.Builder
+		for _, block := range footnote.Blocks {
+			blocks.WriteString(block.String())
+		}
+
+		if footnote.Label != tt.label || footnote.Index != tt.index || blocks.String() != tt.blocks {
+			t.Errorf("footnotes[%d] wrong. expected=%q %d %q, got=%q %d %q",
+				i, tt.label, tt.index, tt.blocks, footnote.Label, footnote.Index, blocks.String())
+		}
+	}
+
+	// 定義の行番号は元の文書の行番号
+	if line := document.Footnotes[0].Blocks[1].(*ast.Paragraph).Token.Line; line != 5 {
+		t.Errorf("wrong line of footnote paragraph. expected=5, got=%d", line)
+	}
+}
+
+// 空行で区切られたパラグラフの構文解析
+func TestParagraphs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"a\n\nb",
+			"<p>a</p>\n<p>b</p>\n",
+		},
+		{
+			"a *b*\n\n\n*c* d\n",
+			"<p>a <em>b</em></p>\n<p><em>c</em> d</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		document := p.ParseDocument()
+
+		actual := document.String()
+		if actual != tt.expected {
+			t.Errorf("input=%q wong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+// 言語指定のないコードブロックの構文解析
+func TestCodeBlockWithoutLang(t *testing.T) {
+	input := "```\ncode\n```"
+
+	expected := "<pre class=\"language-\">\n<code>\ncode\n</code>\n</pre>\n"
+
+	l := lexer.New(input)
+	p := New(l)
+	document := p.ParseDocument()
+
+	actual := document.String()
+	if actual != expected {
+		t.Errorf("input=%q wong. expected=%q, got=%q", input, expected, actual)
+	}
+}
+
+func TestCodeBlockInfoString(t *testing.T) {
+	tests := []struct {
+		input      string
+		lang       string
+		attributes map[string]string
+	}{
+		{"```go\n```", "go", nil},
+		{"```go {linenos=true hl=3-5 start=10}\n```", "go", map[string]string{"linenos": "true", "hl": "3-5", "start": "10"}},
+		{"```go{linenos}\n```", "go", map[string]string{"linenos": "true"}},
+		{"``` {hl=1}\n```", "", map[string]string{"hl": "1"}},
+		{"```sh title=\"run *it* now\" hl=2\n```", "sh", map[string]string{"title": "run *it* now", "hl": "2"}},
+		{"```go\tfile=x\tlinenos\n```", "go", map[string]string{"file": "x", "linenos": "true"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		document := p.ParseDocument()
+
+		codeBlock, ok := document.Blocks[0].(*ast.CodeBlock)
+		if !ok {
+			t.Fatalf("input=%q block is not CodeBlock. got=%T", tt.input, document.Blocks[0])
+		}
+
+		var lang string
+		if codeBlock.Lang != nil {
+			lang = codeBlock.Lang.String()
+		}
+		if lang != tt.lang {
+			t.Errorf("input=%q wrong lang. expected=%q, got=%q", tt.input, tt.lang, lang)
+		}
+		if !reflect.DeepEqual(codeBlock.Attributes, tt.attributes) {
+			t.Errorf("input=%q wrong attributes. expected=%v, got=%v", tt.input, tt.attributes, codeBlock.Attributes)
+		}
+	}
+}
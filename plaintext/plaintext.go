@@ -0,0 +1,274 @@
+package plaintext
+
+import (
+	"bytes"
+	"godown/ast"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 水平線の既定の幅
+const defaultRuleWidth = 72
+
+// ASTを装飾のないテキストとして出力するレンダラー
+//   - 見出しは=(h1)または-(h2以降)で下線を引く
+//   - リストの記号は-
+//   - 強調と打ち消しの記号は取り除く
+//   - コードブロックは4つの空白で字下げする
+//   - リンクは"テキスト (URL)"
+//   - 脚注の参照は[1]、脚注は文書の最後に番号を付けて出力する
+type Renderer struct {
+	Wrap int // 折り返す幅(0の場合は折り返さない)
+}
+
+// srcをテキストに変換する
+func Text(src string, r *Renderer) string {
+	l := lexer.New(src)
+	p := parser.New(l)
+	document := p.ParseDocument()
+
+	var out bytes.Buffer
+	r.Render(&out, document)
+
+	return out.String()
+}
+
+func (r *Renderer) Render(w io.Writer, doc *ast.Document) error {
+	var out bytes.Buffer
+
+	for i, block := range doc.Blocks {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		r.writeBlock(&out, block)
+	}
+	r.writeFootnotes(&out, doc.Footnotes)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// 参照された脚注を水平線で本文と区切って出力する
+// 2行目以降は番号の幅だけ字下げする
+func (r *Renderer) writeFootnotes(out *bytes.Buffer, footnotes []*ast.Footnote) {
+	for _, footnote := range footnotes {
+		if footnote.Index == 0 {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		if footnote.Index == 1 {
+			r.writeBlock(out, &ast.HorizontalRule{})
+			out.WriteString("\n")
+		}
+
+		mark := "[" + strconv.Itoa(footnote.Index) + "] "
+
+		sub := *r
+		if sub.Wrap > len(mark) {
+			sub.Wrap -= len(mark)
+		}
+		var body bytes.Buffer
+		for i, block := range footnote.Blocks {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			sub.writeBlock(&body, block)
+		}
+
+		for i, line := range strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n") {
+			switch {
+			case i == 0:
+				line = mark + line
+			case line != "":
+				line = strings.Repeat(" ", len(mark)) + line
+			}
+			out.WriteString(line + "\n")
+		}
+	}
+}
+
+func (r *Renderer) writeBlock(out *bytes.Buffer, block ast.Block) {
+	switch block := block.(type) {
+	case *ast.Heading:
+		lines := wrap(Inlines(block.Contents), r.Wrap)
+
+		width := 0
+		for _, line := range lines {
+			if n := utf8.RuneCountInString(line); n > width {
+				width = n
+			}
+		}
+
+		underline := "-"
+		if block.Level == 1 {
+			underline = "="
+		}
+
+		writeLines(out, lines)
+		out.WriteString(strings.Repeat(underline, width))
+		out.WriteString("\n")
+	case *ast.DiscList:
+		for i, item := range block.Lists {
+			lines := wrap(Inlines(item), r.Wrap-2)
+			for j, line := range lines {
+				if j == 0 {
+					lines[j] = "- " + line
+				} else {
+					lines[j] = "  " + line
+				}
+			}
+			writeLines(out, lines)
+			r.writeItemBlocks(out, block.ItemBlocks(i))
+		}
+	case *ast.Paragraph:
+		writeLines(out, wrap(Inlines(block.Contents), r.Wrap))
+	case *ast.CodeBlock:
+		var code strings.Builder
+		for _, inline := range block.Contents {
+			code.WriteString(ast.PlainText(inline))
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(code.String(), "\n"), "\n") {
+			if line != "" {
+				out.WriteString("    ")
+				out.WriteString(line)
+			}
+			out.WriteString("\n")
+		}
+	case *ast.HorizontalRule:
+		width := r.Wrap
+		if width <= 0 {
+			width = defaultRuleWidth
+		}
+		out.WriteString(strings.Repeat("-", width))
+		out.WriteString("\n")
+	}
+}
+
+// リストの項目の続きのブロックを、項目の内容の位置まで字下げして出力する
+// 入れ子のリスト以外のブロックの前には空行を置く
+func (r *Renderer) writeItemBlocks(out *bytes.Buffer, blocks []ast.Block) {
+	sub := *r
+	if sub.Wrap > 2 {
+		sub.Wrap -= 2
+	}
+
+	for i, block := range blocks {
+		if _, ok := block.(*ast.DiscList); !ok || i > 0 {
+			out.WriteString("\n")
+		}
+
+		var body bytes.Buffer
+		sub.writeBlock(&body, block)
+		for _, line := range strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n") {
+			if line != "" {
+				out.WriteString("  " + line)
+			}
+			out.WriteString("\n")
+		}
+	}
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+}
+
+// 単語単位で折り返す
+// 改行(強制改行)の位置では必ず改行し、widthより長い単語は分割しない
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, wrapLine(line, width)...)
+	}
+	return lines
+}
+
+func wrapLine(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+
+	var lines []string
+	var line strings.Builder
+	n := 0
+	for _, w := range words {
+		wn := utf8.RuneCountInString(w)
+		if n > 0 && n+1+wn > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		if n > 0 {
+			line.WriteString(" ")
+			n++
+		}
+		line.WriteString(w)
+		n += wn
+	}
+	lines = append(lines, line.String())
+
+	return lines
+}
+
+// godownにはリンクの構文がないため、テキスト中の[テキスト](URL)を置き換える
+var link = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)\)`)
+
+// インライン要素の列をテキストにする
+// インラインコードの中のリンクは置き換えない
+func Inlines(inlines []ast.Inline) string {
+	var out, text strings.Builder
+
+	flush := func() {
+		out.WriteString(link.ReplaceAllString(text.String(), "$1 ($2)"))
+		text.Reset()
+	}
+
+	for _, inline := range inlines {
+		if code, ok := inline.(*ast.InlineCode); ok {
+			flush()
+			out.WriteString(ast.PlainText(code))
+			continue
+		}
+		text.WriteString(plainText(inline))
+	}
+	flush()
+
+	return out.String()
+}
+
+// 脚注の参照を[1]として、装飾を除いたテキストにする
+// ソフト改行は空白、強制改行は改行にする
+func plainText(node ast.Node) string {
+	var out strings.Builder
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Text:
+			out.WriteString(n.Content)
+		case *ast.SoftBreak:
+			out.WriteString(" ")
+		case *ast.HardBreak:
+			out.WriteString("\n")
+		case *ast.FootnoteReference:
+			out.WriteString("[" + strconv.Itoa(n.Index) + "]")
+		}
+		return true
+	})
+
+	return out.String()
+}
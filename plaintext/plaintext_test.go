@@ -0,0 +1,59 @@
+package plaintext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"# Heading*2*\n## Sub\n",
+			"Heading2\n========\n\nSub\n---\n",
+		},
+		{
+			"***a*** **b** ~~c~~ `*d*`\n- x\n- *y*\n",
+			"a b c *d*\n\n- x\n- y\n",
+		},
+		{
+			"```go\nfunc main() {\n\n}\n```\n",
+			"    func main() {\n\n    }\n",
+		},
+		{
+			"- a\n  - b\n- c\n\n  d\n",
+			"- a\n  - b\n- c\n\n  d\n",
+		},
+		{
+			"a[^1] b\n\n[^1]: one\n\n    two\n",
+			"a[1] b\n\n" + strings.Repeat("-", 72) + "\n\n[1] one\n\n    two\n",
+		},
+		{
+			"see [docs](http://example.com/a) and `[a](b)`\n",
+			"see docs (http://example.com/a) and [a](b)\n",
+		},
+		{
+			"soft\nbreak  \nhard\\\nbreak\n",
+			"soft break\nhard\nbreak\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Text(tt.input, &Renderer{})
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestTextWrap(t *testing.T) {
+	input := "aaa bbb ccc ddd\n- eee fff ggg\n\n---\n"
+	expected := "aaa bbb\nccc ddd\n\n- eee fff\n  ggg\n\n----------\n"
+
+	actual := Text(input, &Renderer{Wrap: 10})
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+}
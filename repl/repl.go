@@ -2,38 +2,148 @@ package repl
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"godown/ast"
 	"godown/evaluator"
 	"godown/lexer"
 	"godown/parser"
+	"godown/plaintext"
+	"godown/token"
 	"io"
+	"strings"
 )
 
 const PROMPT = ">> "
 
+// 入力に対して表示する内容
+type mode string
+
+const (
+	modeTokens mode = ":tokens" // lexerのトークン列
+	modeAST    mode = ":ast"    // ASTの木
+	modeHTML   mode = ":html"   // HTML
+	modeText   mode = ":text"   // プレーンテキスト
+)
+
+const help = `:tokens  print the token stream
+:ast     print the AST as an indented tree
+:html    print rendered HTML (default)
+:text    print rendered plain text
+:begin   start a multi-line snippet, finished by :end
+:help    show this help
+`
+
+// 複数行の入力の途中で表示するプロンプト
+const CONTINUATION_PROMPT = ".. "
+
+// 入力を1行ずつ読み込み、スニペットが完結するごとに表示する
+// 空行か:endまでを1つのスニペットとして扱い、コードブロックの中の空行ではスニペットは終わらない
+// :beginから:endまでは空行を含めて1つのスニペットになる
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
+	current := modeHTML
+
+	var buf []string
+	fence := false    // コードブロックの途中
+	explicit := false // :beginで始めたスニペットの途中
+
+	flush := func() {
+		if strings.TrimSpace(strings.Join(buf, "")) != "" {
+			io.WriteString(out, eval(strings.Join(buf, "\n")+"\n", current))
+		}
+		buf = nil
+		fence = false
+		explicit = false
+	}
 
 	for {
-		fmt.Printf(PROMPT)
+		if len(buf) > 0 || explicit {
+			io.WriteString(out, CONTINUATION_PROMPT)
+		} else {
+			io.WriteString(out, PROMPT)
+		}
+
 		scanned := scanner.Scan()
 		if !scanned {
+			flush()
 			return
 		}
 
 		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
 
-		document := p.ParseDocument()
+		if strings.TrimSpace(line) == ":end" {
+			flush()
+			continue
+		}
+
+		// スニペットの外で:で始まる行はメタコマンド
+		if len(buf) == 0 && !explicit && strings.HasPrefix(line, ":") {
+			switch command := mode(strings.TrimSpace(line)); command {
+			case modeTokens, modeAST, modeHTML, modeText:
+				current = command
+			case ":begin":
+				explicit = true
+			case ":help":
+				io.WriteString(out, help)
+			default:
+				fmt.Fprintf(out, "unknown command %q (try :help)\n", command)
+			}
+			continue
+		}
+
+		if !explicit && !fence && strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		buf = append(buf, line)
+		if isFence(line) {
+			fence = !fence
+		}
+	}
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// 入力をmodeに応じた形式にする
+func eval(input string, m mode) string {
+	if m == modeTokens {
+		return tokens(input)
+	}
+
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	document := p.ParseDocument()
 
+	switch m {
+	case modeAST:
+		return ast.Dump(document)
+	case modeText:
+		var out bytes.Buffer
+		(&plaintext.Renderer{}).Render(&out, document)
+		return out.String()
+	default:
 		evaluated := evaluator.Eval(document)
-		// if evaluated != nil {
-		// 	io.WriteString(out, evaluated.Inspect())
-		// 	io.WriteString(out, "\n")
-		// }
+		return evaluated.Inspect()
+	}
+}
 
-		io.WriteString(out, evaluated.Inspect())
-		// io.WriteString(out, "\n")
+// トークンを1行ずつ"行:列 種類 リテラル"の形式にする
+func tokens(input string) string {
+	var out strings.Builder
+
+	l := lexer.New(input)
+	for {
+		tok := l.NextToken()
+		fmt.Fprintf(&out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+		if tok.Type == token.EOF {
+			break
+		}
 	}
+
+	return out.String()
 }
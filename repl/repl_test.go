@@ -0,0 +1,75 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"# a\n\n", ">> .. <h1>a</h1>\n>> "},
+		{":text\n# a\n\n", ">> >> .. a\n=\n>> "},
+		{":ast\n*a*\n\n", ">> >> .. Document\n  Paragraph @1:1\n    Emphasis level=1 @1:1\n      Text \"a\" @1:2\n>> "},
+		{":tokens\n# a\n\n", ">> >> .. 1:1\t#\t\"#\"\n1:2\t \t\" \"\n1:3\tTEXT\t\"a\"\n1:4\tCR\t\"\\n\"\n2:1\tEOF\t\"\"\n>> "},
+		{":text\n:html\n`a`\n\n", ">> >> >> .. <p><code>a</code></p>\n>> "},
+		{":foo\n", ">> unknown command \":foo\" (try :help)\n>> "},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input), &out)
+
+		if out.String() != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			":text\n- a\n- b\n\n# c\n",
+			">> >> .. .. - a\n- b\n>> .. c\n=\n",
+		},
+		{
+			":text\n```go\nx\n\ny\n```\n\n",
+			">> >> .. .. .. .. .. " + "    x\n\n    y\n>> ",
+		},
+		{
+			":text\n:begin\na\n\nb\n:end\n",
+			">> >> .. .. .. .. a\n\nb\n>> ",
+		},
+		{
+			":text\n- a\n- b",
+			">> >> .. .. - a\n- b\n",
+		},
+		{
+			":text\n- a\n:end\n",
+			">> >> .. - a\n>> ",
+		},
+		{
+			":text\nfoo\nbar\n\n",
+			">> >> .. .. foo bar\n>> ",
+		},
+		{
+			"Foo\n===\n",
+			">> .. .. <h1>Foo</h1>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input), &out)
+
+		if out.String() != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package roff
+
+import (
+	"bytes"
+	"godown/ast"
+	"godown/lexer"
+	"godown/parser"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ASTをman(7)形式のroffとして出力するレンダラー
+//   - .THはフロントマターのtitle、section、date、source、manualから出力する
+//   - h1とh2は.SH、h3以降は.SS
+//   - 前後が空白の強調は.B/.I、それ以外はフォントを切り替えるエスケープ
+//   - コードブロックは.nfと.fiで囲む
+//   - リストの項目は.IP
+//   - 脚注の参照は[1]、脚注は最後の.SH NOTESに番号を付けて出力する
+type Renderer struct {
+	Title   string // フロントマターにtitleがない場合のタイトル
+	Section string // フロントマターにsectionがない場合のセクション(空の場合は1)
+}
+
+// srcをroffに変換する
+func Man(src string, r *Renderer) string {
+	l := lexer.New(src)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions|parser.FrontMatter)
+	document := p.ParseDocument()
+
+	var out bytes.Buffer
+	r.Render(&out, document)
+
+	return out.String()
+}
+
+func (r *Renderer) Render(w io.Writer, doc *ast.Document) error {
+	var out bytes.Buffer
+
+	r.writeTitle(&out, doc.FrontMatter)
+
+	for _, block := range doc.Blocks {
+		writeBlock(&out, block)
+	}
+	writeFootnotes(&out, doc.Footnotes)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// .THを出力する
+// 後ろの空の引数は省略する
+func (r *Renderer) writeTitle(out *bytes.Buffer, frontMatter map[string]string) {
+	field := func(key, fallback string) string {
+		if value, ok := frontMatter[key]; ok {
+			return value
+		}
+		return fallback
+	}
+
+	section := r.Section
+	if section == "" {
+		section = "1"
+	}
+
+	args := []string{
+		strings.ToUpper(field("title", r.Title)),
+		field("section", section),
+		field("date", ""),
+		field("source", ""),
+		field("manual", ""),
+	}
+	for len(args) > 2 && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+
+	out.WriteString(".TH")
+	for _, arg := range args {
+		out.WriteString(" " + quote(arg))
+	}
+	out.WriteString("\n")
+}
+
+func writeBlock(out *bytes.Buffer, block ast.Block) {
+	switch block := block.(type) {
+	case *ast.Heading:
+		macro := ".SH"
+		if block.Level > 2 {
+			macro = ".SS"
+		}
+		out.WriteString(macro + " " + quote(strings.TrimSpace(ast.PlainText(block))) + "\n")
+	case *ast.DiscList:
+		for i, item := range block.Lists {
+			out.WriteString(".IP \\(bu 2\n")
+			writeText(out, item)
+
+			// 項目の続きのブロックは項目の内容の位置まで字下げする
+			if blocks := block.ItemBlocks(i); len(blocks) > 0 {
+				out.WriteString(".RS 2\n")
+				for _, b := range blocks {
+					writeBlock(out, b)
+				}
+				out.WriteString(".RE\n")
+			}
+		}
+	case *ast.Paragraph:
+		out.WriteString(".PP\n")
+		writeText(out, block.Contents)
+	case *ast.CodeBlock:
+		var code strings.Builder
+		for _, inline := range block.Contents {
+			code.WriteString(ast.PlainText(inline))
+		}
+
+		out.WriteString(".PP\n.RS 4\n.nf\n")
+		for _, line := range strings.Split(strings.TrimSuffix(code.String(), "\n"), "\n") {
+			out.WriteString(escapeLine(escape(line)) + "\n")
+		}
+		out.WriteString(".fi\n.RE\n")
+	case *ast.HorizontalRule:
+		out.WriteString(".sp\n")
+	}
+}
+
+// 参照された脚注を.IPの段落として出力する
+// 2つ目以降のパラグラフは見出しのない.IPで字下げを揃える
+func writeFootnotes(out *bytes.Buffer, footnotes []*ast.Footnote) {
+	for _, footnote := range footnotes {
+		if footnote.Index == 0 {
+			continue
+		}
+		if footnote.Index == 1 {
+			out.WriteString(".SH \"NOTES\"\n")
+		}
+
+		out.WriteString(".IP " + quote("["+strconv.Itoa(footnote.Index)+"]") + " 4\n")
+		for i, block := range footnote.Blocks {
+			paragraph, ok := block.(*ast.Paragraph)
+			if !ok {
+				writeBlock(out, block)
+				continue
+			}
+			if i > 0 {
+				out.WriteString(".IP \"\" 4\n")
+			}
+			writeText(out, paragraph.Contents)
+		}
+	}
+}
+
+// インライン要素の列を出力する
+// 前後が空白の強調は.B/.Iの行にし、それ以外はフォントを切り替えるエスケープにする
+func writeText(out *bytes.Buffer, inlines []ast.Inline) {
+	var line strings.Builder
+
+	flush := func() {
+		text := strings.TrimSpace(line.String())
+		line.Reset()
+		for _, l := range strings.Split(text, "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				out.WriteString(escapeLine(l) + "\n")
+			}
+		}
+	}
+
+	skipSpace := false
+	for i, inline := range inlines {
+		if skipSpace {
+			if text, ok := inline.(*ast.Text); ok {
+				inline = &ast.Text{Token: text.Token, Content: strings.TrimLeftFunc(text.Content, unicode.IsSpace)}
+			}
+			skipSpace = false
+		}
+
+		if _, ok := inline.(*ast.HardBreak); ok {
+			flush()
+			out.WriteString(".br\n")
+			continue
+		}
+
+		if macro := emphasisMacro(inline); macro != "" &&
+			endsWithSpace(line.String()) && startsWithSpace(inlines[i+1:]) {
+			flush()
+			out.WriteString(macro + " " + quote(ast.PlainText(inline)) + "\n")
+			skipSpace = true
+			continue
+		}
+
+		line.WriteString(Inline(inline))
+	}
+	flush()
+}
+
+// .Bまたは.Iで出力できる強調の場合はマクロの名前を返す
+func emphasisMacro(inline ast.Inline) string {
+	emphasis, ok := inline.(*ast.Emphasis)
+	if !ok {
+		return ""
+	}
+
+	for _, content := range emphasis.Contents {
+		if _, ok := content.(*ast.Text); !ok {
+			return ""
+		}
+	}
+
+	switch emphasis.Level {
+	case 1:
+		return ".I"
+	case 2:
+		return ".B"
+	}
+	return ""
+}
+
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return s == "" || unicode.IsSpace(r)
+}
+
+func startsWithSpace(inlines []ast.Inline) bool {
+	if len(inlines) == 0 {
+		return true
+	}
+	switch inlines[0].(type) {
+	case *ast.SoftBreak, *ast.HardBreak:
+		return true
+	}
+	text, ok := inlines[0].(*ast.Text)
+	if !ok {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text.Content)
+	return text.Content == "" || unicode.IsSpace(r)
+}
+
+// インライン要素の列をroffにする
+func Inlines(inlines []ast.Inline) string {
+	return inlinesIn(inlines, `\fR`)
+}
+
+// インライン要素をroffにする
+func Inline(inline ast.Inline) string {
+	return inlineIn(inline, `\fR`)
+}
+
+// フォントfontの中にあるインライン要素の列をroffにする
+func inlinesIn(inlines []ast.Inline, font string) string {
+	var out strings.Builder
+
+	for _, inline := range inlines {
+		out.WriteString(inlineIn(inline, font))
+	}
+
+	return out.String()
+}
+
+// フォントfontの中にあるインライン要素をroffにする
+// \fPは直前のフォントにしか戻らず入れ子の強調の後でフォントがずれるため、外側のフォントを明示して戻す
+func inlineIn(inline ast.Inline, font string) string {
+	switch inline := inline.(type) {
+	case *ast.Emphasis:
+		inner := emphasisFont(inline.Level, font)
+		return inner + inlinesIn(inline.Contents, inner) + font
+	case *ast.InlineCode:
+		return `\fB` + escape(ast.PlainText(inline)) + font
+	case *ast.Strikethrough:
+		// roffには打ち消し線がないため、テキストのみ出力する
+		return inlinesIn(inline.Contents, font)
+	case *ast.FootnoteReference:
+		return "[" + strconv.Itoa(inline.Index) + "]"
+	case *ast.SoftBreak, *ast.HardBreak:
+		return "\n"
+	case *ast.Text:
+		return escape(inline.Content)
+	}
+
+	return ""
+}
+
+// 外側のフォントouterの中にある強調のフォント
+// 強調の中の強い強調(またはその逆)は太字の斜体にする
+func emphasisFont(level int, outer string) string {
+	italic := level != 2 || outer == `\fI` || outer == `\f(BI`
+	bold := level != 1 || outer == `\fB` || outer == `\f(BI`
+
+	switch {
+	case italic && bold:
+		return `\f(BI`
+	case bold:
+		return `\fB`
+	}
+	return `\fI`
+}
+
+// バックスラッシュとハイフンをエスケープする
+// -はそのままではハイフンとして組まれ、--flagのようなオプションをコピーや検索できなくなるため\-にする
+var escaper = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// 制御行として解釈されないように、行頭の.と'をエスケープする
+func escapeLine(line string) string {
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		return `\&` + line
+	}
+	return line
+}
+
+// マクロの引数として"で囲む
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(escape(s), `"`, `\(dq`) + `"`
+}
@@ -0,0 +1,68 @@
+package roff
+
+import "testing"
+
+func TestMan(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"---\ntitle: godown\nsection: 1\ndate: 2024-01-01\n---\n# Name\n### Options\n",
+			".TH \"GODOWN\" \"1\" \"2024\\-01\\-01\"\n.SH \"Name\"\n.SS \"Options\"\n",
+		},
+		{
+			"run *fast* and **safe** now\n",
+			".TH \"\" \"1\"\n.PP\nrun\n.I \"fast\"\nand\n.B \"safe\"\nnow\n",
+		},
+		{
+			"- a\n  - b\n\n  c\n",
+			".TH \"\" \"1\"\n.IP \\(bu 2\na\n.RS 2\n.IP \\(bu 2\nb\n.PP\nc\n.RE\n",
+		},
+		{
+			"a**b**c ***d*** `e\\f` ~~g~~\n",
+			".TH \"\" \"1\"\n.PP\na\\fBb\\fRc \\f(BId\\fR \\fBe\\ef\\fR g\n",
+		},
+		{
+			"**a *b* `c`** d\n",
+			".TH \"\" \"1\"\n.PP\n\\fBa \\f(BIb\\fB \\fBc\\fB\\fR d\n",
+		},
+		{
+			"use --flag or `-v`\n\n```sh\ngodown -format man\n```\n",
+			".TH \"\" \"1\"\n.PP\nuse \\-\\-flag or \\fB\\-v\\fR\n.PP\n.RS 4\n.nf\ngodown \\-format man\n.fi\n.RE\n",
+		},
+		{
+			"soft\n*break*  \nhard\n",
+			".TH \"\" \"1\"\n.PP\nsoft\n.I \"break\"\n.br\nhard\n",
+		},
+		{
+			"- .hidden\n- 'quote\n",
+			".TH \"\" \"1\"\n.IP \\(bu 2\n\\&.hidden\n.IP \\(bu 2\n\\&'quote\n",
+		},
+		{
+			"```sh\n.\\\" comment\n```\n---\n",
+			".TH \"\" \"1\"\n.PP\n.RS 4\n.nf\n\\&.\\e\" comment\n.fi\n.RE\n.sp\n",
+		},
+		{
+			"see[^1]\n\n[^1]: one\n\n    two\n",
+			".TH \"\" \"1\"\n.PP\nsee[1]\n.SH \"NOTES\"\n.IP \"[1]\" 4\none\n.IP \"\" 4\ntwo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := Man(tt.input, &Renderer{})
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestManTitle(t *testing.T) {
+	input := "---\nsection: 5\n---\ntext\n"
+	expected := ".TH \"GODOWN\" \"5\"\n.PP\ntext\n"
+
+	actual := Man(input, &Renderer{Title: "godown", Section: "8"})
+	if actual != expected {
+		t.Errorf("input=%q wrong. expected=%q, got=%q", input, expected, actual)
+	}
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"godown/converter"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ライブリロード用のServer-Sent Eventsのエンドポイント
+	EventsPath = "/_godown/events"
+
+	// 既定のファイル監視間隔
+	DefaultPollInterval = 500 * time.Millisecond
+)
+
+// ページに埋め込むライブリロード用のスクリプト
+const reloadScript = `<script>
+(function() {
+  var source = new EventSource("` + EventsPath + `?path=" + encodeURIComponent(location.pathname));
+  source.addEventListener("reload", function() { location.reload(); });
+})();
+</script>
+`
+
+// ディレクトリの一覧
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<meta charset="utf-8">
+<title>Index of {{.Path}}</title>
+<body>
+<h1>Index of {{.Path}}</h1>
+<ul>
+{{if ne .Path "/"}}<li><a href="../">../</a></li>
+{{end}}{{range .Entries}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// ディレクトリ以下のMarkdownをリクエストのたびに変換して配信するHTTPハンドラ
+type Server struct {
+	Root    string            // 配信するディレクトリ
+	Options converter.Options // 変換の設定
+
+	PollInterval time.Duration // ライブリロードでファイルの変更を確認する間隔
+}
+
+// ハンドラを生成する
+func New(root string, opts converter.Options) *Server {
+	return &Server{Root: root, Options: opts, PollInterval: DefaultPollInterval}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == EventsPath {
+		s.serveEvents(w, r)
+		return
+	}
+
+	name, ok := s.resolve(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case info.IsDir():
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		s.serveIndex(w, r, name)
+	case converter.IsMarkdown(name):
+		s.serveMarkdown(w, r, name)
+	default:
+		http.ServeFile(w, r, name)
+	}
+}
+
+// URLのパスをRoot以下のファイルパスに変換する
+func (s *Server) resolve(urlPath string) (string, bool) {
+	cleaned := path.Clean("/" + urlPath)
+	if strings.Contains(cleaned, "\x00") {
+		return "", false
+	}
+
+	return filepath.Join(s.Root, filepath.FromSlash(cleaned)), true
+}
+
+// Markdownを変換し、ライブリロード用のスクリプトを埋め込んで返す
+func (s *Server) serveMarkdown(w http.ResponseWriter, r *http.Request, name string) {
+	in, err := os.Open(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer in.Close()
+
+	var buf bytes.Buffer
+	if err := converter.Convert(r.Context(), in, &buf, s.Options); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := InjectScript(buf.Bytes())
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// HTMLの</body>の直前にライブリロード用のスクリプトを挿入する
+func InjectScript(page []byte) []byte {
+	i := bytes.LastIndex(page, []byte("</body>"))
+	if i < 0 {
+		return append(page, reloadScript...)
+	}
+
+	var out bytes.Buffer
+	out.Write(page[:i])
+	out.WriteString(reloadScript)
+	out.Write(page[i:])
+
+	return out.Bytes()
+}
+
+// ディレクトリの一覧を返す
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var dirs, files []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if e.IsDir() {
+			dirs = append(dirs, e.Name()+"/")
+		} else {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+
+	data := struct {
+		Path    string
+		Entries []string
+	}{
+		Path:    path.Clean("/" + r.URL.Path),
+		Entries: append(dirs, files...),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ファイルが変更されたらreloadイベントを送るServer-Sent Eventsのストリーム
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	name, ok := s.resolve(r.URL.Query().Get("path"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	last := stat(name)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		if current := stat(name); current != last {
+			fmt.Fprint(w, "event: reload\ndata: reload\n\n")
+			flusher.Flush()
+			last = current
+		}
+	}
+}
+
+// 変更を検出するためのファイルの状態
+func stat(name string) string {
+	info, err := os.Stat(name)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
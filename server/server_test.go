@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bufio"
+	"godown/converter"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"doc.md":       "# doc\n",
+		"sub/page.md":  "# page\n",
+		"sub/data.txt": "data",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := converter.DefaultOptions()
+	opts.Theme = "../res/godown.css"
+
+	return New(root, opts), root
+}
+
+func TestServeHTTP(t *testing.T) {
+	s, _ := testServer(t)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		contains    []string
+	}{
+		{"/doc.md", http.StatusOK, "text/html", []string{"<h1>doc</h1>", "EventSource", "</script>\n</body>"}},
+		{"/", http.StatusOK, "text/html", []string{`<a href="sub/">sub/</a>`, `<a href="doc.md">doc.md</a>`}},
+		{"/sub/", http.StatusOK, "text/html", []string{`<a href="../">../</a>`, `<a href="page.md">page.md</a>`}},
+		{"/sub", http.StatusMovedPermanently, "", nil},
+		{"/sub/data.txt", http.StatusOK, "text/plain", []string{"data"}},
+		{"/../../etc/passwd", http.StatusNotFound, "", nil},
+		{"/missing.md", http.StatusNotFound, "", nil},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("path=%s status wrong. expected=%d, got=%d", tt.path, tt.status, rec.Code)
+			continue
+		}
+		if !strings.HasPrefix(rec.Header().Get("Content-Type"), tt.contentType) {
+			t.Errorf("path=%s content type wrong. expected=%s, got=%s",
+				tt.path, tt.contentType, rec.Header().Get("Content-Type"))
+		}
+		for _, c := range tt.contains {
+			if !strings.Contains(rec.Body.String(), c) {
+				t.Errorf("path=%s body does not contain %q", tt.path, c)
+			}
+		}
+	}
+}
+
+func TestInjectScript(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<body>a</body>\n</html>", "<body>a" + reloadScript + "</body>\n</html>"},
+		{"<p>a</p>", "<p>a</p>" + reloadScript},
+	}
+
+	for _, tt := range tests {
+		actual := string(InjectScript([]byte(tt.input)))
+		if actual != tt.expected {
+			t.Errorf("input=%q wrong. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestLiveReload(t *testing.T) {
+	s, root := testServer(t)
+	s.PollInterval = 5 * time.Millisecond
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + EventsPath + "?path=/doc.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type wrong. got=%s", ct)
+	}
+
+	events := make(chan string)
+	go func() {
+		r := bufio.NewReader(resp.Body)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			events <- strings.TrimSpace(line)
+		}
+	}()
+
+	if line := <-events; line != ": connected" {
+		t.Fatalf("first line wrong. got=%q", line)
+	}
+	<-events
+
+	mtime := time.Now().Add(time.Hour)
+	if err := os.WriteFile(filepath.Join(root, "doc.md"), []byte("# changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(filepath.Join(root, "doc.md"), mtime, mtime)
+
+	select {
+	case line := <-events:
+		if line != "event: reload" {
+			t.Errorf("event wrong. got=%q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload event timed out")
+	}
+}
@@ -0,0 +1,148 @@
+package tangle
+
+import (
+	"fmt"
+	"godown/ast"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 組み立てたファイル
+type File struct {
+	Path    string // 属性fileで指定したパス
+	Content string
+}
+
+// コードブロック中のチャンクの参照(<<name>>だけの行)
+var reference = regexp.MustCompile(`^(\s*)<<([^<>]+)>>\s*$`)
+
+// 文書のコードブロックからファイルを組み立てる
+//   - file=pathを指定したコードブロックはそのファイルの内容になる
+//   - chunk=nameを指定したコードブロックは名前付きのチャンクになる
+//   - <<name>>だけの行はチャンクの内容に置き換える(行の字下げはチャンクの各行に付ける)
+//
+// 同じファイルやチャンクを指定したコードブロックは文書の順に連結する
+// ファイルは最初に現れた順に返す
+func Tangle(doc *ast.Document) ([]File, error) {
+	var order []string
+	files := map[string]*strings.Builder{}
+	chunks := map[string]*strings.Builder{}
+
+	ast.Inspect(doc, func(node ast.Node) bool {
+		block, ok := node.(*ast.CodeBlock)
+		if !ok {
+			return true
+		}
+
+		var code strings.Builder
+		for _, c := range block.Contents {
+			code.WriteString(c.String())
+		}
+
+		if name, ok := block.Attributes["chunk"]; ok {
+			if chunks[name] == nil {
+				chunks[name] = &strings.Builder{}
+			}
+			chunks[name].WriteString(code.String())
+		}
+		if path, ok := block.Attributes["file"]; ok {
+			if files[path] == nil {
+				files[path] = &strings.Builder{}
+				order = append(order, path)
+			}
+			files[path].WriteString(code.String())
+		}
+
+		return false
+	})
+
+	t := &tangler{chunks: map[string]string{}, expanding: map[string]bool{}}
+	for name, b := range chunks {
+		t.chunks[name] = b.String()
+	}
+
+	var result []File
+	for _, path := range order {
+		content, err := t.expand(files[path].String(), "")
+		if err != nil {
+			return nil, fmt.Errorf("tangle: %s: %w", path, err)
+		}
+		result = append(result, File{Path: path, Content: content})
+	}
+
+	return result, nil
+}
+
+type tangler struct {
+	chunks    map[string]string
+	expanding map[string]bool // 展開中のチャンク(循環の検出に使う)
+}
+
+// チャンクの参照を再帰的に展開し、各行の先頭にindentを付ける
+func (t *tangler) expand(code, indent string) (string, error) {
+	var out strings.Builder
+
+	for _, line := range strings.SplitAfter(code, "\n") {
+		if line == "" {
+			continue
+		}
+
+		m := reference.FindStringSubmatch(strings.TrimSuffix(line, "\n"))
+		if m == nil {
+			if strings.TrimSpace(line) != "" {
+				out.WriteString(indent)
+			}
+			out.WriteString(line)
+			continue
+		}
+
+		name := strings.TrimSpace(m[2])
+		chunk, ok := t.chunks[name]
+		if !ok {
+			return "", fmt.Errorf("undefined chunk <<%s>>", name)
+		}
+		if t.expanding[name] {
+			return "", fmt.Errorf("chunk <<%s>> refers to itself", name)
+		}
+
+		t.expanding[name] = true
+		expanded, err := t.expand(chunk, indent+m[1])
+		t.expanding[name] = false
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(expanded)
+	}
+
+	return out.String(), nil
+}
+
+// ファイルをdirの下に書き出す
+// dirの外を指すパスはエラーにする
+func Write(dir string, files []File) error {
+	for _, f := range files {
+		path, err := Resolve(dir, f.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(f.Content), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 属性fileのパスをdirの下のパスにする
+func Resolve(dir, path string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if path == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("tangle: %q is outside the output directory", path)
+	}
+	return filepath.Join(dir, clean), nil
+}
@@ -0,0 +1,81 @@
+package tangle
+
+import (
+	"godown/lexer"
+	"godown/parser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tangle(input string) ([]File, error) {
+	l := lexer.New(input)
+	p := parser.NewWithExtensions(l, parser.CommonExtensions)
+	return Tangle(p.ParseDocument())
+}
+
+func TestTangle(t *testing.T) {
+	input := "# Tutorial\n\n" +
+		"```go file=main.go\npackage main\n\n<<imports>>\n\nfunc main() {\n\t<<body>>\n}\n```\n\n" +
+		"```go chunk=imports\nimport \"fmt\"\n```\n\n" +
+		"```go chunk=body\nx := 1\n\n<<print>>\n```\n\n" +
+		"```go chunk=print\nfmt.Println(x)\n```\n\n" +
+		"```sh file=run.sh\ngo run main.go\n```\n\n" +
+		"```go\nignored()\n```\n\n" +
+		"```sh file=run.sh\necho done\n```\n"
+
+	expected := []File{
+		{Path: "main.go", Content: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tx := 1\n\n\tfmt.Println(x)\n}\n"},
+		{Path: "run.sh", Content: "go run main.go\necho done\n"},
+	}
+
+	actual, err := tangle(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("wrong. expected=%q, got=%q", expected, actual)
+	}
+}
+
+func TestTangleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"```go file=a.go\n<<missing>>\n```\n", "undefined chunk <<missing>>"},
+		{"```go file=a.go\n<<a>>\n```\n```go chunk=a\n<<b>>\n```\n```go chunk=b\n<<a>>\n```\n", "chunk <<a>> refers to itself"},
+	}
+
+	for _, tt := range tests {
+		_, err := tangle(tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("input=%q wrong error. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	err := Write(dir, []File{{Path: "cmd/app/main.go", Content: "package main\n"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "cmd", "app", "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "package main\n" {
+		t.Errorf("wrong content. got=%q", b)
+	}
+
+	for _, path := range []string{"../x.go", "/etc/x", "a/../../x", ""} {
+		if err := Write(dir, []File{{Path: path}}); err == nil {
+			t.Errorf("path=%q was written outside the output directory", path)
+		}
+	}
+}
@@ -7,6 +7,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType // トークンの種類を区別する
 	Literal string    // トークンのリテラル表現
+	Line    int       // トークンの開始位置の行番号(1始まり)
+	Column  int       // トークンの開始位置の列番号(1始まり、バイト単位)
 }
 
 const (
@@ -17,11 +19,13 @@ const (
 	IGETA  = "#"
 	HYPHEN = "-"
 
-	ASTERISK  = "*"
-	BACKQUOTE = "`"
-	TILDE     = "~"
-	TEXT      = "TEXT" // 文字列
-	SPACE     = " "
+	ASTERISK   = "*"
+	UNDERSCORE = "_"
+	BACKQUOTE  = "`"
+	TILDE      = "~"
+	TEXT       = "TEXT" // 文字列
+	SPACE      = " "
+	TAB        = "\t"
 	// INT       = "INT"  // 数字
 	// DOT       = "."
 )
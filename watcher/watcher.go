@@ -0,0 +1,273 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"godown/converter"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// 既定のポーリング間隔
+	DefaultInterval = 500 * time.Millisecond
+	// 既定のデバウンス時間
+	DefaultDebounce = 200 * time.Millisecond
+)
+
+// 1回の再ビルドの結果
+type Summary struct {
+	Rebuilt  []string               // 変換またはコピーしたファイル
+	Removed  []string               // 削除されたファイル
+	Errors   []*converter.FileError // 失敗したファイル
+	Duration time.Duration          // 再ビルドにかかった時間
+}
+
+// 変更を検出するためのファイルの状態
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// 入力ファイルまたはディレクトリをポーリングし、変更されたファイルだけを再変換する
+type Watcher struct {
+	Input   string            // 監視するMarkdownファイルまたはディレクトリ
+	Output  string            // 出力先のファイルまたはディレクトリ
+	Options converter.Options // 変換の設定
+
+	Interval time.Duration // ポーリング間隔
+	Debounce time.Duration // 最後の変更からこの時間だけ待ってから再ビルドする
+
+	// 再ビルドのたびに呼ばれる
+	OnBuild func(Summary)
+	// 監視を続けられるファイルごとのエラーのたびに呼ばれる
+	OnError func(*converter.FileError)
+
+	dir   bool
+	files map[string]fileState
+}
+
+// 監視を生成する
+// outputが空の場合、ファイルの監視では入力と同じ場所の.htmlに、
+// ディレクトリの監視では入力ディレクトリ名に-htmlを付けたディレクトリに書き出す
+func New(input, output string, opts converter.Options) (*Watcher, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if output == "" {
+		if info.IsDir() {
+			output = input + "-html"
+		} else {
+			output = converter.HTMLPath(input)
+		}
+	}
+
+	if info.IsDir() {
+		opts.RewriteLinks = true
+	}
+
+	return &Watcher{
+		Input:    input,
+		Output:   output,
+		Options:  opts,
+		Interval: DefaultInterval,
+		Debounce: DefaultDebounce,
+		dir:      info.IsDir(),
+		files:    map[string]fileState{},
+	}, nil
+}
+
+// 前回のScanから変更・追加・削除されたファイルを返す
+// パスは入力ディレクトリからの相対パス(ファイルの監視では入力のパス)
+// 走査中に削除されたファイルは読み飛ばし、それ以外のファイルごとのエラーはOnErrorに渡して
+// 前回の状態のままにする。入力そのものを読めない場合だけエラーを返す
+func (w *Watcher) Scan() ([]string, error) {
+	current := map[string]fileState{}
+
+	if w.dir {
+		absOut, _ := filepath.Abs(w.Output)
+		err := filepath.WalkDir(w.Input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == w.Input {
+					return err
+				}
+				w.skip(current, path, err)
+				return nil
+			}
+			if d.IsDir() {
+				if abs, err := filepath.Abs(path); err == nil && abs == absOut {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				w.skip(current, path, err)
+				return nil
+			}
+			rel, err := filepath.Rel(w.Input, path)
+			if err != nil {
+				return err
+			}
+			current[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		info, err := os.Stat(w.Input)
+		switch {
+		case err == nil:
+			current[w.Input] = fileState{modTime: info.ModTime(), size: info.Size()}
+		case !errors.Is(err, fs.ErrNotExist):
+			w.skip(current, w.Input, err)
+		}
+	}
+
+	var changed []string
+	for path, state := range current {
+		if prev, ok := w.files[path]; !ok || prev != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.files {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+
+	w.files = current
+
+	return changed, nil
+}
+
+// 走査できなかったファイルを読み飛ばす
+// 削除されたファイル以外はエラーを報告し、削除されたとみなさないように前回の状態を残す
+// ディレクトリの場合は、その中のファイルの前回の状態を残す
+func (w *Watcher) skip(current map[string]fileState, path string, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+
+	rel := path
+	if w.dir {
+		if r, err := filepath.Rel(w.Input, path); err == nil {
+			rel = r
+		}
+	}
+
+	if w.OnError != nil {
+		w.OnError(&converter.FileError{Path: rel, Err: err})
+	}
+
+	prefix := rel + string(filepath.Separator)
+	for p, state := range w.files {
+		if p == rel || strings.HasPrefix(p, prefix) {
+			current[p] = state
+		}
+	}
+}
+
+// 入力ファイルに対応する出力ファイルのパス
+func (w *Watcher) outputPath(path string) string {
+	switch {
+	case !w.dir:
+		return w.Output
+	case converter.IsMarkdown(path):
+		return filepath.Join(w.Output, converter.HTMLPath(path))
+	default:
+		return filepath.Join(w.Output, path)
+	}
+}
+
+// 指定したファイルを再ビルドする
+// 削除されたファイルは、対応する出力ファイルも削除する
+func (w *Watcher) Build(ctx context.Context, paths []string) Summary {
+	var summary Summary
+	start := time.Now()
+
+	for _, path := range paths {
+		if _, ok := w.files[path]; !ok {
+			if err := os.Remove(w.outputPath(path)); err != nil && !os.IsNotExist(err) {
+				summary.Errors = append(summary.Errors, &converter.FileError{Path: path, Err: err})
+			}
+			summary.Removed = append(summary.Removed, path)
+			continue
+		}
+
+		var err error
+		switch {
+		case !w.dir:
+			err = converter.ConvertFile(ctx, path, w.Output, w.Options)
+		case converter.IsMarkdown(path):
+			err = converter.ConvertFile(ctx, filepath.Join(w.Input, path), w.outputPath(path), w.Options)
+		default:
+			err = converter.CopyFile(filepath.Join(w.Input, path), w.outputPath(path))
+		}
+
+		if err != nil {
+			summary.Errors = append(summary.Errors, &converter.FileError{Path: path, Err: err})
+		} else {
+			summary.Rebuilt = append(summary.Rebuilt, path)
+		}
+	}
+
+	summary.Duration = time.Since(start)
+
+	return summary
+}
+
+// ctxがキャンセルされるまで監視を続ける
+// 起動時にはすべてのファイルをビルドする
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	pending := map[string]bool{}
+	var lastChange time.Time
+
+	for {
+		changed, err := w.Scan()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if len(changed) > 0 {
+			for _, path := range changed {
+				pending[path] = true
+			}
+			lastChange = now
+		}
+
+		if len(pending) > 0 && now.Sub(lastChange) >= w.Debounce {
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = map[string]bool{}
+
+			summary := w.Build(ctx, paths)
+			if w.OnBuild != nil {
+				w.OnBuild(summary)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
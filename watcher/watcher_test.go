@@ -0,0 +1,197 @@
+package watcher
+
+import (
+	"context"
+	"godown/converter"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testOptions() converter.Options {
+	opts := converter.DefaultOptions()
+	opts.Theme = "../res/godown.css"
+	return opts
+}
+
+func touch(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanAndBuild(t *testing.T) {
+	in := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	base := time.Now().Add(-time.Hour)
+
+	touch(t, filepath.Join(in, "a.md"), "# a\n", base)
+	touch(t, filepath.Join(in, "sub", "b.md"), "# b\n", base)
+	touch(t, filepath.Join(in, "img.png"), "png", base)
+
+	w, err := New(in, out, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a.md", "img.png", filepath.Join("sub", "b.md")}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Fatalf("first scan wrong. expected=%v, got=%v", expected, changed)
+	}
+
+	summary := w.Build(context.Background(), changed)
+	if !reflect.DeepEqual(summary.Rebuilt, expected) || len(summary.Errors) != 0 {
+		t.Fatalf("first build wrong. got=%+v", summary)
+	}
+
+	changed, _ = w.Scan()
+	if len(changed) != 0 {
+		t.Errorf("unchanged tree reported changes: %v", changed)
+	}
+
+	touch(t, filepath.Join(in, "a.md"), "# changed\n", base.Add(time.Minute))
+	os.Remove(filepath.Join(in, "img.png"))
+
+	changed, _ = w.Scan()
+	expected = []string{"a.md", "img.png"}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Fatalf("second scan wrong. expected=%v, got=%v", expected, changed)
+	}
+
+	summary = w.Build(context.Background(), changed)
+	if !reflect.DeepEqual(summary.Rebuilt, []string{"a.md"}) {
+		t.Errorf("rebuilt wrong. got=%v", summary.Rebuilt)
+	}
+	if !reflect.DeepEqual(summary.Removed, []string{"img.png"}) {
+		t.Errorf("removed wrong. got=%v", summary.Removed)
+	}
+
+	html, err := os.ReadFile(filepath.Join(out, "a.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "<h1>changed</h1>") {
+		t.Errorf("a.html was not rebuilt")
+	}
+	if _, err := os.Stat(filepath.Join(out, "img.png")); !os.IsNotExist(err) {
+		t.Errorf("img.png was not removed from the output: %v", err)
+	}
+
+	os.Remove(filepath.Join(in, "sub", "b.md"))
+
+	changed, _ = w.Scan()
+	summary = w.Build(context.Background(), changed)
+	if !reflect.DeepEqual(summary.Removed, []string{filepath.Join("sub", "b.md")}) || len(summary.Errors) != 0 {
+		t.Errorf("third build wrong. got=%+v", summary)
+	}
+	if _, err := os.Stat(filepath.Join(out, "sub", "b.html")); !os.IsNotExist(err) {
+		t.Errorf("sub/b.html was not removed from the output: %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "doc.md")
+	touch(t, input, "# doc\n", time.Now().Add(-time.Hour))
+
+	w, err := New(input, "", testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Output != filepath.Join(dir, "doc.html") {
+		t.Errorf("default output wrong. got=%s", w.Output)
+	}
+
+	w.Interval = 5 * time.Millisecond
+	w.Debounce = 20 * time.Millisecond
+
+	builds := make(chan Summary, 10)
+	w.OnBuild = func(s Summary) { builds <- s }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case s := <-builds:
+		if !reflect.DeepEqual(s.Rebuilt, []string{input}) {
+			t.Errorf("initial build wrong. got=%+v", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("initial build timed out")
+	}
+
+	// 連続した変更は1回の再ビルドにまとめられる
+	for i := 0; i < 3; i++ {
+		touch(t, input, "# doc\n\nedit\n", time.Now().Add(time.Duration(i)*time.Second))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	select {
+	case s := <-builds:
+		if !reflect.DeepEqual(s.Rebuilt, []string{input}) {
+			t.Errorf("rebuild wrong. got=%+v", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("rebuild timed out")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
+
+func TestScanSkipsFileErrors(t *testing.T) {
+	in := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	touch(t, filepath.Join(in, "a.md"), "# a\n", base)
+	touch(t, filepath.Join(in, "sub", "b.md"), "# b\n", base)
+
+	w, err := New(in, filepath.Join(t.TempDir(), "out"), testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []string
+	w.OnError = func(e *converter.FileError) { reported = append(reported, e.Path) }
+
+	if _, err := w.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 走査中に削除されたファイルは報告しない
+	current := map[string]fileState{}
+	w.skip(current, filepath.Join(in, ".a.md.swp"), fs.ErrNotExist)
+	if len(current) != 0 || len(reported) != 0 {
+		t.Errorf("missing file was not skipped. current=%v, reported=%v", current, reported)
+	}
+
+	// 読めないディレクトリは報告し、中のファイルを削除されたとみなさない
+	w.skip(current, filepath.Join(in, "sub"), fs.ErrPermission)
+	if !reflect.DeepEqual(reported, []string{"sub"}) {
+		t.Errorf("reported wrong. got=%v", reported)
+	}
+	if _, ok := current[filepath.Join("sub", "b.md")]; !ok || len(current) != 1 {
+		t.Errorf("previous state was not kept. got=%v", current)
+	}
+
+	if _, err := w.Scan(); err != nil {
+		t.Errorf("Scan returned error: %v", err)
+	}
+}